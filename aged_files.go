@@ -0,0 +1,120 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// AgedFileFixturesArgs describes a series of files whose names embed their
+// dates, spaced at Interval and aged relative to Now, as created by backup,
+// log rotation and retention tooling.
+type AgedFileFixturesArgs struct {
+	NameLayout string           // time.Format layout for names, e.g. "backup-2006-01-02.tar"
+	Now        time.Time        // Time ages are relative to; defaults to the RootFixture's BaseTime, else time.Now()
+	MinAge     time.Duration    // Age of the newest file
+	MaxAge     time.Duration    // Files older than this are not generated
+	Interval   time.Duration    // Age difference between consecutive files; defaults to 24h
+	Gaps       []int            // Indexes in the series (0 is the newest) to leave out
+	Duplicates map[int]int      // Extra copies for indexes in the series, named with a "-N" suffix before the extension
+	File       *FileFixtureArgs // Template for each file, e.g. Content or Permissions
+}
+
+// agedFile is one generated entry in an aged file series.
+type agedFile struct {
+	name  dt.RelFilepath
+	mtime time.Time
+}
+
+// series returns the names and modification times of the files described by
+// args, newest first, with each duplicate immediately following its original.
+func (args *AgedFileFixturesArgs) series(now time.Time) (files []agedFile) {
+	index := 0
+	for age := args.MinAge; age <= args.MaxAge; age += args.Interval {
+		i := index
+		index++
+		if slices.Contains(args.Gaps, i) {
+			continue
+		}
+		ts := now.Add(-age)
+		name := ts.Format(args.NameLayout)
+		files = append(files, agedFile{name: dt.RelFilepath(name), mtime: ts})
+		for n := 1; n <= args.Duplicates[i]; n++ {
+			files = append(files, agedFile{
+				name:  dt.RelFilepath(duplicateName(name, n)),
+				mtime: ts.Add(time.Duration(n) * time.Minute),
+			})
+		}
+	}
+	return files
+}
+
+// duplicateName inserts "-n" before the extension of name.
+func duplicateName(name string, n int) string {
+	ext := filepath.Ext(name)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), n, ext)
+}
+
+// addAgedFileFixtures validates args and adds the series it describes via add.
+func addAgedFileFixtures(t testing.TB, parent Fixture, args *AgedFileFixturesArgs, add func(dt.RelFilepath, *FileFixtureArgs) *FileFixture) (ffs []*FileFixture) {
+	var now time.Time
+	var a AgedFileFixturesArgs
+
+	if args == nil || args.NameLayout == "" {
+		fatalf(t, "NameLayout not set for aged file fixtures being added to %s", parent.fixtureLabel())
+		goto end
+	}
+	// Default a copy, leaving the caller's args as they declared them
+	a = *args
+	if a.Interval <= 0 {
+		a.Interval = 24 * time.Hour
+	}
+	if a.MaxAge < a.MinAge {
+		fatalf(t, "MaxAge %s is less than MinAge %s for aged file fixtures being added to %s",
+			a.MaxAge, a.MinAge, parent.fixtureLabel())
+		goto end
+	}
+	now = a.Now
+	if now.IsZero() && rootOf(parent) != nil {
+		now = rootOf(parent).BaseTime
+	}
+	if now.IsZero() {
+		now = time.Now()
+	}
+	for _, af := range a.series(now) {
+		ffa := FileFixtureArgs{}
+		if a.File != nil {
+			ffa = *a.File
+		}
+		ffa.Name = af.name
+		ffa.ModifiedTime = af.mtime
+		ffs = append(ffs, add(af.name, &ffa))
+	}
+end:
+	return ffs
+}
+
+// AddAgedFileFixtures adds a series of files named from args.NameLayout with
+// modification times matching the dates in their names, e.g. for testing
+// retention and rotation. Returns the files newest first.
+func (df *DirFixture) AddAgedFileFixtures(t testing.TB, args *AgedFileFixturesArgs) []*FileFixture {
+	return addAgedFileFixtures(t, df, args, func(name dt.RelFilepath, ffa *FileFixtureArgs) *FileFixture {
+		return df.AddFileFixture(t, name, ffa)
+	})
+}
+
+// AddAgedFileFixtures adds a series of files named from args.NameLayout with
+// modification times matching the dates in their names, e.g. for testing
+// retention and rotation. Returns the files newest first.
+func (rf *RepoFixture) AddAgedFileFixtures(t testing.TB, args *AgedFileFixturesArgs) []*FileFixture {
+	return addAgedFileFixtures(t, rf, args, func(name dt.RelFilepath, ffa *FileFixtureArgs) *FileFixture {
+		return rf.AddFileFixture(t, name, ffa)
+	})
+}
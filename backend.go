@@ -0,0 +1,166 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// Backend is the filesystem fixtures are created in. Paths are absolute,
+// in the form of the host OS, and methods behave as their os package
+// counterparts. OSBackend is the default; MemoryBackend keeps the tree in
+// memory for fast tests of code that accepts a Backend or an fs.FS.
+type Backend interface {
+	MkdirTemp(dir, pattern string) (string, error)
+	MkdirAll(path string, perm fs.FileMode) error
+	OpenFile(name string, flag int, perm fs.FileMode) (BackendFile, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Stat(name string) (fs.FileInfo, error)
+	Lstat(name string) (fs.FileInfo, error)
+	Chmod(name string, mode fs.FileMode) error
+	Chtimes(name string, atime, mtime time.Time) error
+	Symlink(oldname, newname string) error
+	Readlink(name string) (string, error)
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	DirFS(dir string) fs.FS
+}
+
+// BackendFile is an open file in a Backend.
+type BackendFile interface {
+	fs.File
+	io.Writer
+	io.Seeker
+}
+
+// _ is a compile-time check to ensure OSBackend implements Backend.
+var _ Backend = OSBackend{}
+
+// OSBackend creates fixtures on disk via the os package.
+type OSBackend struct{}
+
+func (OSBackend) MkdirTemp(dir, pattern string) (string, error) {
+	return os.MkdirTemp(dir, pattern)
+}
+
+func (OSBackend) MkdirAll(path string, perm fs.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (OSBackend) OpenFile(name string, flag int, perm fs.FileMode) (BackendFile, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		// Avoid returning a non-nil interface holding a nil *os.File
+		return nil, err
+	}
+	return f, nil
+}
+
+func (OSBackend) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (OSBackend) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (OSBackend) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (OSBackend) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OSBackend) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (OSBackend) Chmod(name string, mode fs.FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+// Chtimes leaves zero times unchanged and, on Linux, supports times outside
+// the years 1678 to 2262.
+func (OSBackend) Chtimes(name string, atime, mtime time.Time) error {
+	return chtimes(name, atime, mtime)
+}
+
+func (OSBackend) Symlink(oldname, newname string) error {
+	return os.Symlink(oldname, newname)
+}
+
+func (OSBackend) Readlink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+func (OSBackend) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+func (OSBackend) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// RemoveAll removes path and everything beneath it, restoring permissions
+// and retrying transient failures as needed.
+func (OSBackend) RemoveAll(path string) error {
+	return removeTree(dt.DirPath(path))
+}
+
+func (OSBackend) DirFS(dir string) fs.FS {
+	return os.DirFS(dir)
+}
+
+// requireOSBackend fails t if the fixture labelled label, being added to
+// parent, uses feature while its root creates fixtures in another Backend.
+// An empty feature means none is used.
+func requireOSBackend(t testing.TB, parent Fixture, label string, feature string) {
+	if feature == "" {
+		return
+	}
+	rf := rootOf(parent)
+	if rf == nil || isOSBackend(rf.Backend()) {
+		return
+	}
+	fatalf(t, "%s uses %s, which needs the OS backend rather than %T", label, feature, rf.Backend())
+}
+
+// isOSBackend reports whether b creates fixtures on disk, which fixtures
+// needing OS facilities such as locks, sockets or ownership require.
+func isOSBackend(b Backend) bool {
+	_, ok := b.(OSBackend)
+	return ok
+}
+
+// createTemp creates and opens a new file in dir in b, named by pattern with
+// its last "*" replaced by a random string, as os.CreateTemp() does.
+func createTemp(b Backend, dir, pattern string, perm fs.FileMode) (f BackendFile, name string, err error) {
+	prefix, suffix := pattern, ""
+	i := strings.LastIndex(pattern, "*")
+	if i >= 0 {
+		prefix, suffix = pattern[:i], pattern[i+1:]
+	}
+	for range 10000 {
+		name = filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10)+suffix)
+		f, err = b.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if !errors.Is(err, fs.ErrExist) {
+			return f, name, err
+		}
+	}
+	return nil, "", &fs.PathError{Op: "createtemp", Path: filepath.Join(dir, pattern), Err: fs.ErrExist}
+}
@@ -0,0 +1,134 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+const (
+	removeAttempts   = 5                     // Attempts per entry before giving up
+	removeRetryDelay = 10 * time.Millisecond // Initial delay between attempts, doubled each retry
+)
+
+// RemoveFailure describes an entry that could not be removed during cleanup.
+type RemoveFailure struct {
+	Path dt.EntryPath
+	Err  error
+}
+
+// RemoveError summarizes every entry that could not be removed during cleanup.
+type RemoveError struct {
+	Dir      dt.DirPath
+	Failures []RemoveFailure
+}
+
+func (e *RemoveError) Error() string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("%d entries could not be removed from '%s':", len(e.Failures), e.Dir))
+	for _, f := range e.Failures {
+		sb.WriteString(fmt.Sprintf("\n  %s: %v", f.Path, f.Err))
+	}
+	return sb.String()
+}
+
+func (e *RemoveError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// removeTree removes dir and everything beneath it. Owner read/write/exec
+// permissions are restored on each directory before it is listed so trees
+// containing 0000 directories and read-only files can still be removed, and
+// entries are then removed bottom-up, retrying transient failures. Returns a
+// *RemoveError listing anything that still could not be removed.
+func removeTree(dir dt.DirPath) error {
+	// Fast path for the common case
+	if dir.RemoveAll() == nil {
+		return nil
+	}
+	re := &RemoveError{Dir: dir}
+	re.remove(dt.EntryPath(dir))
+	if len(re.Failures) == 0 {
+		return nil
+	}
+	return re
+}
+
+// remove restores permissions on ep, removes its children if it is a
+// directory, then removes ep itself, recording any failures.
+func (e *RemoveError) remove(ep dt.EntryPath) {
+	info, err := ep.Lstat()
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		e.fail(ep, err)
+		return
+	}
+	if info.Mode()&fs.ModeSymlink == 0 {
+		// Best effort; a failure here surfaces as a failure to remove below.
+		_ = os.Chmod(string(ep), info.Mode().Perm()|0700)
+	}
+	if info.IsDir() {
+		entries, err := os.ReadDir(string(ep))
+		if err != nil {
+			e.fail(ep, err)
+		}
+		for _, entry := range entries {
+			e.remove(dt.EntryPathJoin(ep, entry.Name()))
+		}
+	}
+	err = retryTransient(func() error {
+		return os.Remove(string(ep))
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		e.fail(ep, err)
+	}
+}
+
+// fail records that ep could not be removed.
+func (e *RemoveError) fail(ep dt.EntryPath, err error) {
+	e.Failures = append(e.Failures, RemoveFailure{Path: ep, Err: err})
+}
+
+// retryTransient calls fn until it succeeds, fails with a non-transient
+// error, or removeAttempts is reached.
+func retryTransient(fn func() error) (err error) {
+	delay := removeRetryDelay
+	for range removeAttempts {
+		err = fn()
+		if !isTransient(err) {
+			break
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+	return err
+}
+
+// isTransient reports whether err is likely to succeed if retried, e.g. an
+// entry held busy by another process or a directory still being written to.
+func isTransient(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, syscall.EBUSY),
+		errors.Is(err, syscall.EAGAIN),
+		errors.Is(err, syscall.EINTR),
+		errors.Is(err, syscall.ENOTEMPTY):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"crypto/sha256"
+	"maps"
+	"path/filepath"
+	"testing"
+)
+
+// Clone returns an uncreated deep copy of the declared fixture tree, with
+// every Parent link pointing at the copies, so one base declaration can be
+// varied per test case without the variants sharing state. The copy shares
+// the Backend and any ContentFunc, which is evaluated again on Create().
+func (rf *RootFixture) Clone() *RootFixture {
+	c, _ := rf.clone()
+	return c
+}
+
+// clone returns an uncreated deep copy of rf and the cloner that made it,
+// which maps each original fixture to its copy.
+func (rf *RootFixture) clone() (*RootFixture, cloner) {
+	c := &RootFixture{
+		DirPrefix:    rf.DirPrefix,
+		BaseDir:      rf.BaseDir,
+		BaseTime:     rf.BaseTime,
+		backend:      rf.backend,
+		useTBTempDir: rf.useTBTempDir,
+		t:            rf.t,
+	}
+	cl := cloner{copies: map[any]any{rf: c}}
+	c.FileFixtures = cl.files(rf.FileFixtures, c)
+	c.ChildFixtures = cl.children(rf.ChildFixtures, c)
+	c.pathIndex = cl.index(rf.pathIndex)
+	return c, cl
+}
+
+// cloner deep copies fixtures, recording the copy of each original so
+// references to them can be rewired.
+type cloner struct {
+	copies map[any]any
+}
+
+// files returns copies of files declared in parent's copy.
+func (cl cloner) files(files []*FileFixture, parent Fixture) []*FileFixture {
+	cs := make([]*FileFixture, len(files))
+	for i, ff := range files {
+		c := *ff
+		c.Filepath = ""
+		c.Parent = parent
+		c.XAttrs = maps.Clone(ff.XAttrs)
+		c.Vars = maps.Clone(ff.Vars)
+		c.Lock = clonePtr(ff.Lock)
+		c.HoldOpen = clonePtr(ff.HoldOpen)
+		c.Writer = clonePtr(ff.Writer)
+		c.liveWriter = nil
+		c.lock = nil
+		c.held = nil
+		c.handle = nil
+		c.evaluated = false
+		c.stamp = fileStamp{}
+		c.sum = [sha256.Size]byte{}
+		c.created = false
+		cl.copies[ff] = &c
+		cs[i] = &c
+	}
+	return cs
+}
+
+// children returns copies of the directory and repository fixtures
+// declared in parent's copy, and everything beneath them.
+func (cl cloner) children(children []Fixture, parent Fixture) []Fixture {
+	cs := make([]Fixture, 0, len(children))
+	for _, child := range children {
+		switch f := child.(type) {
+		case *DirFixture:
+			c := cl.dir(f, parent)
+			cl.contents(c, f, c)
+			cs = append(cs, c)
+		case *RepoFixture:
+			c := &RepoFixture{Parent: parent, t: f.t}
+			c.DirFixture = cl.dir(f.DirFixture, c)
+			cl.copies[f] = c
+			cl.contents(c.DirFixture, f.DirFixture, c)
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// dir returns a copy of df, without its contents, with parent as its parent.
+func (cl cloner) dir(df *DirFixture, parent Fixture) *DirFixture {
+	c := *df
+	c.Parent = parent
+	c.XAttrs = maps.Clone(df.XAttrs)
+	c.FileFixtures = nil
+	c.ChildFixtures = nil
+	c.dir = ""
+	c.created = false
+	cl.copies[df] = &c
+	return &c
+}
+
+// contents copies the files and children of src into dst, with self, the
+// fixture that owns dst, as their parent.
+func (cl cloner) contents(dst, src *DirFixture, self Fixture) {
+	dst.FileFixtures = cl.files(src.FileFixtures, self)
+	dst.ChildFixtures = cl.children(src.ChildFixtures, self)
+}
+
+// index returns a copy of idx referring to the copied fixtures.
+func (cl cloner) index(idx pathIndex) pathIndex {
+	if idx == nil {
+		return nil
+	}
+	c := make(pathIndex, len(idx))
+	for path, e := range idx {
+		ce := *e
+		ce.fixture = cl.copies[e.fixture]
+		if e.parent != nil {
+			ce.parent, _ = cl.copies[e.parent].(Fixture)
+		}
+		c[path] = &ce
+	}
+	return c
+}
+
+// clonePtr returns a pointer to a shallow copy of *p, or nil.
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	return &c
+}
+
+// declaredEntry returns the index entry of the fixture declared at relPath,
+// failing t if there is none or the tree has already been created.
+func (rf *RootFixture) declaredEntry(t testing.TB, relPath string) *pathEntry {
+	if rf.created {
+		fatalf(t, "RootFixture '%s' has already been created; modify a Clone() instead", rf.DirPrefix)
+		return nil
+	}
+	e, ok := rf.pathIndex[filepath.Clean(filepath.FromSlash(relPath))]
+	if !ok || e.implied {
+		fatalf(t, "No fixture is declared at '%s' in RootFixture '%s'", relPath, rf.DirPrefix)
+		return nil
+	}
+	return e
+}
+
+// Remove removes the fixture declared at relPath, and everything declared
+// beneath it, from the uncreated tree, e.g. for a variant of a Clone() with
+// one file missing.
+func (rf *RootFixture) Remove(t testing.TB, relPath string) {
+	e := rf.declaredEntry(t, relPath)
+	if e == nil {
+		return
+	}
+	rf.pathIndex.remove(e)
+}
+
+// ReplaceFile replaces the file declared at relPath in the uncreated tree
+// with one declared by args, in the same parent fixture.
+func (rf *RootFixture) ReplaceFile(t testing.TB, relPath string, args *FileFixtureArgs) *FileFixture {
+	var replacement FileFixtureArgs
+
+	e := rf.declaredEntry(t, relPath)
+	if e == nil {
+		return nil
+	}
+	ff, ok := e.fixture.(*FileFixture)
+	if !ok {
+		fatalf(t, "Fixture declared at '%s' in RootFixture '%s' is not a file", relPath, rf.DirPrefix)
+		return nil
+	}
+	if args != nil {
+		replacement = *args
+	}
+	replacement.Override = true
+	parent, ok := ff.Parent.(fixtureAdder)
+	if !ok {
+		fatalf(t, "Cannot add to the parent of '%s' in RootFixture '%s'", relPath, rf.DirPrefix)
+		return nil
+	}
+	return parent.AddFileFixture(t, ff.Name, &replacement)
+}
+
+// SetPermissions changes the permissions of the file or directory declared
+// at relPath in the uncreated tree, clearing any Deny preset.
+func (rf *RootFixture) SetPermissions(t testing.TB, relPath string, perm int) {
+	e := rf.declaredEntry(t, relPath)
+	if e == nil {
+		return
+	}
+	switch f := e.fixture.(type) {
+	case *FileFixture:
+		f.Permissions, f.Deny = perm, NoDenial
+	case *DirFixture:
+		f.Permissions, f.Deny = perm, NoDenial
+	case *RepoFixture:
+		f.Permissions, f.Deny = perm, NoDenial
+	}
+}
@@ -0,0 +1,162 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// AccessDenial is a preset permission-denied scenario for a file or
+// directory fixture. Each preset replaces the fixture's Permissions and is
+// verified after creation to actually deny access for the current user.
+type AccessDenial int
+
+const (
+	NoDenial         AccessDenial = iota // Access is governed by Permissions as usual
+	UnreadableFile                       // File mode 0000, so opening it fails
+	WriteOnlyFile                        // File mode 0200, so it can be written but not read
+	UnlistableDir                        // Directory mode 0311, so it can be traversed but not listed
+	UntraversableDir                     // Directory mode 0644, so it can be listed but not traversed
+	ReadOnlyDir                          // Directory mode 0555, so entries cannot be created or removed
+)
+
+func (d AccessDenial) String() string {
+	switch d {
+	case NoDenial:
+		return "no denial"
+	case UnreadableFile:
+		return "unreadable file"
+	case WriteOnlyFile:
+		return "write-only file"
+	case UnlistableDir:
+		return "unlistable directory"
+	case UntraversableDir:
+		return "untraversable directory"
+	case ReadOnlyDir:
+		return "read-only directory"
+	}
+	return "unknown denial"
+}
+
+// permissions returns the mode that produces the denial.
+func (d AccessDenial) permissions() int {
+	switch d {
+	case UnreadableFile:
+		return 0000
+	case WriteOnlyFile:
+		return 0200
+	case UnlistableDir:
+		return 0311
+	case UntraversableDir:
+		return 0644
+	case ReadOnlyDir:
+		return 0555
+	}
+	return 0
+}
+
+// forDir reports whether the denial applies to directories.
+func (d AccessDenial) forDir() bool {
+	return d == UnlistableDir || d == UntraversableDir || d == ReadOnlyDir
+}
+
+// checkDenial fails t if denial does not suit the kind of fixture labelled
+// label, and skips the test if the current user bypasses permission checks.
+func checkDenial(t testing.TB, label string, denial AccessDenial, isDir bool) {
+	if denial == NoDenial || t == nil {
+		return
+	}
+	t.Helper()
+	if denial.forDir() != isDir {
+		fatalf(t, "Access denial '%s' cannot be applied to %s", denial, label)
+		return
+	}
+	switch {
+	case runtime.GOOS == "windows":
+		t.Skipf("Skipping as access denial '%s' for %s needs Unix permissions", denial, label)
+	case os.Geteuid() == 0:
+		t.Skipf("Skipping as access denial '%s' for %s cannot apply when running as root, which bypasses permission checks", denial, label)
+	}
+}
+
+// deniedPath is an entry whose denial is verified once the tree is complete.
+type deniedPath struct {
+	path   dt.EntryPath
+	denial AccessDenial
+}
+
+// recordDenial records path to verify denial on once the tree is complete.
+func (rf *RootFixture) recordDenial(path dt.EntryPath, denial AccessDenial) {
+	if denial == NoDenial {
+		return
+	}
+	rf.deniedPaths = append(rf.deniedPaths, deniedPath{path: path, denial: denial})
+}
+
+// verifyDenials probes every recorded denial, returning an error wrapping
+// ErrAccessNotDenied for any that the current user can bypass, and clears them.
+func (rf *RootFixture) verifyDenials() error {
+	var errs []error
+
+	for _, dp := range rf.deniedPaths {
+		err := probeDenial(dp.path, dp.denial)
+		if err != nil {
+			errs = append(errs, dt.NewErr(ErrAccessNotDenied, "path", dp.path, "denial", dp.denial, err))
+		}
+	}
+	rf.deniedPaths = nil
+	return dt.CombineErrs(errs)
+}
+
+// errNotDenied reports an operation a denial should have prevented.
+var errNotDenied = errors.New("operation was permitted")
+
+// probeDenial attempts the operations denial should prevent on path,
+// returning an error if any was permitted or failed other than with
+// permission denied.
+func probeDenial(path dt.EntryPath, denial AccessDenial) (err error) {
+	var f *os.File
+	var op string
+
+	p := string(path)
+	switch denial {
+	case UnreadableFile, WriteOnlyFile:
+		op = "open for reading"
+		f, err = os.Open(p)
+	case UnlistableDir:
+		op = "list"
+		_, err = os.ReadDir(p)
+	case UntraversableDir:
+		op = "traverse"
+		_, err = os.Lstat(filepath.Join(p, ".fsfix-probe"))
+	case ReadOnlyDir:
+		op = "create entry in"
+		f, err = os.OpenFile(filepath.Join(p, ".fsfix-probe"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			err = os.Remove(f.Name())
+		}
+	}
+	if f != nil {
+		_ = f.Close()
+		err = dt.NewErr(errNotDenied, "op", op)
+		goto end
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		err = nil
+		goto end
+	}
+	if err == nil {
+		err = dt.NewErr(errNotDenied, "op", op)
+		goto end
+	}
+	err = dt.WithErr(err, "op", op)
+end:
+	return err
+}
@@ -0,0 +1,34 @@
+//go:build linux || darwin
+
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"io/fs"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// mknod creates a character or block device node at path.
+func mknod(path string, kind FileKind, perm os.FileMode, major, minor uint32) (err error) {
+	mode := uint32(perm) | syscall.S_IFCHR
+	if kind == BlockDeviceKind {
+		mode = uint32(perm) | syscall.S_IFBLK
+	}
+	err = syscall.Mknod(path, mode, mkdev(major, minor))
+	if err != nil {
+		err = &fs.PathError{Op: "mknod", Path: path, Err: err}
+	}
+	return err
+}
+
+// mkdev encodes a device number as the platform's mknod expects.
+func mkdev(major, minor uint32) int {
+	if runtime.GOOS == "darwin" {
+		return int(major<<24 | minor&0xffffff)
+	}
+	return int(uint64(major&0xfff)<<8 | uint64(minor&0xff) |
+		uint64(minor&^0xff)<<12 | uint64(major&^0xfff)<<32)
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// mknod reports that device nodes are only supported on Linux and macOS.
+func mknod(path string, _ FileKind, _ os.FileMode, _, _ uint32) error {
+	return &fs.PathError{Op: "mknod", Path: path, Err: errors.ErrUnsupported}
+}
@@ -3,7 +3,8 @@
 package fsfix
 
 import (
-	"os"
+	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -15,26 +16,41 @@ var _ Fixture = (*DirFixture)(nil)
 
 // DirFixture represents a dir directory fixture with optional Git repository.
 type DirFixture struct {
-	Name          dt.PathSegments // Name of the dir directory
-	FileFixtures  []*FileFixture  // Files to create within this dir
-	ChildFixtures []Fixture       // Subdirectories or Projects to create within this dir
-	ModifiedTime  time.Time       // Modification time for the dir directory
-	Permissions   int             // Directory permissions (e.g., 0755)
-	dir           dt.DirPath      // Full path to the created directory
-	Parent        Fixture         // Parent test fixture
-	created       bool
-	t             *testing.T
+	Name           dt.PathSegments   // Name of the dir directory
+	FileFixtures   []*FileFixture    // Files to create within this dir
+	ChildFixtures  []Fixture         // Subdirectories or Projects to create within this dir
+	ModifiedTime   time.Time         // Modification time for the dir directory
+	AccessedTime   time.Time         // Access time for the directory; defaults to ModifiedTime
+	ModifiedOffset string            // Modification time offset from the RootFixture's BaseTime
+	AccessedOffset string            // Access time offset from the RootFixture's BaseTime
+	Permissions    int               // Directory permissions (e.g., 0755)
+	Deny           AccessDenial      // Preset access denial, e.g. UnlistableDir
+	Owner          string            // Owner to lchown to, numeric or by name
+	Group          string            // Group to lchown to, numeric or by name
+	XAttrs         map[string][]byte // Extended attributes to set on the directory
+	dir            dt.DirPath        // Full path to the created directory
+	Parent         Fixture           // Parent test fixture
+	AllowEscape    bool              // Permit Name to resolve outside the fixture root
+	created        bool
+	t              testing.TB
 }
 
 func (df *DirFixture) RelativePath() dt.DirPath {
 	return dt.DirPathJoin(df.Parent.RelativePath(), df.Name)
 }
 
+func (df *DirFixture) parentFixture() Fixture {
+	return df.Parent
+}
+
+func (df *DirFixture) fixtureLabel() string {
+	return fmt.Sprintf("DirFixture '%s'", df.Name)
+}
+
 // ensureCreated forces a failure if called before Create() is called.
 func (df *DirFixture) ensureCreated() {
-	df.t.Helper()
 	if !df.created {
-		df.t.Fatalf("DirFixture '%s' has not yet been created", df.Name)
+		fatalf(df.t, "DirFixture '%s' has not yet been created", df.Name)
 	}
 }
 
@@ -46,26 +62,96 @@ func (df *DirFixture) Dir() dt.DirPath {
 
 // DirFixtureArgs contains arguments for creating a DirFixture.
 type DirFixtureArgs struct {
-	Files        []*FileFixture // Files to create within this dir
-	Permissions  int            // Directory permissions
-	ModifiedTime time.Time      // Modification time for the directory
+	Files          []*FileFixture    // Files to create within this dir
+	Permissions    int               // Directory permissions
+	Deny           AccessDenial      // Preset denial, e.g. UnlistableDir; replaces Permissions, skips the test as root
+	Owner          string            // Owner to lchown to, numeric or by name; skips the test if not permitted
+	Group          string            // Group to lchown to, numeric or by name; skips the test if not permitted
+	XAttrs         map[string][]byte // Extended attributes, e.g. "user.origin"; skips the test if unsupported
+	ModifiedTime   time.Time         // Modification time for the directory
+	AccessedTime   time.Time         // Access time for the directory; defaults to ModifiedTime
+	ModifiedOffset string            // Modification time offset from the RootFixture's BaseTime, e.g. "-72h"
+	AccessedOffset string            // Access time offset from the RootFixture's BaseTime, e.g. "-72h"
+	AllowEscape    bool              // Permit the name to resolve outside the fixture root
+	Override       bool              // Intentionally replace an earlier fixture declaring the same path
 }
 
 // newDirFixture creates a new directory fixture with the specified name and arguments.
-func newDirFixture(t *testing.T, name dt.PathSegments, parent Fixture, args *DirFixtureArgs) *DirFixture {
+func newDirFixture(t testing.TB, name dt.PathSegments, parent Fixture, args *DirFixtureArgs) *DirFixture {
 	if args == nil {
 		args = &DirFixtureArgs{}
 	}
 	if args.Permissions == 0 {
 		args.Permissions = 0755
 	}
-	return &DirFixture{
-		Name:         name,
-		Parent:       parent,
-		FileFixtures: args.Files,
-		ModifiedTime: args.ModifiedTime,
-		Permissions:  args.Permissions,
-		t:            t,
+	df := &DirFixture{
+		Name:           name,
+		Parent:         parent,
+		FileFixtures:   args.Files,
+		ModifiedTime:   args.ModifiedTime,
+		Permissions:    args.Permissions,
+		Deny:           args.Deny,
+		Owner:          args.Owner,
+		Group:          args.Group,
+		XAttrs:         args.XAttrs,
+		AccessedTime:   args.AccessedTime,
+		ModifiedOffset: args.ModifiedOffset,
+		AccessedOffset: args.AccessedOffset,
+		AllowEscape:    args.AllowEscape,
+		t:              t,
+	}
+	err := df.times().validateOffsets()
+	if err != nil {
+		fatalf(t, "Invalid time offset for dir fixture '%s'; %v", name, err)
+	}
+	if df.Deny != NoDenial {
+		df.Permissions = df.Deny.permissions()
+	}
+	requireOSBackend(t, parent, "dir fixture '"+string(name)+"'", df.osOnlyFeature())
+	checkDenial(t, "dir fixture '"+string(name)+"'", df.Deny, true)
+	skipUnlessCanChown(t, "dir fixture '"+string(name)+"'", df.Owner, df.Group)
+	return df
+}
+
+// adoptFiles makes parent the parent of the files passed via the Files of
+// DirFixtureArgs or RepoFixtureArgs, gives them the defaults AddFileFixture()
+// would, and registers their paths, failing t for any name AddFileFixture()
+// would reject.
+func adoptFiles(t testing.TB, parent Fixture, files []*FileFixture) {
+	if t != nil {
+		t.Helper()
+	}
+	for _, ff := range files {
+		if ff.Parent == nil {
+			ff.Parent = parent
+		}
+		ff.applyPermissionDefaults()
+		validateChild(t, parent, "FileFixture", string(ff.Name), ff.AllowEscape)
+		registerChild(t, parent, "FileFixture", string(ff.Name), ff, false, false)
+	}
+}
+
+// osOnlyFeature names the first feature the directory fixture uses that
+// needs the OS backend, or returns "".
+func (df *DirFixture) osOnlyFeature() string {
+	switch {
+	case df.Owner != "" || df.Group != "":
+		return "Owner or Group"
+	case len(df.XAttrs) > 0:
+		return "XAttrs"
+	case df.Deny != NoDenial:
+		return "Deny"
+	}
+	return ""
+}
+
+// times returns the timestamps declared for this directory fixture.
+func (df *DirFixture) times() fixtureTimes {
+	return fixtureTimes{
+		ModifiedTime:   df.ModifiedTime,
+		AccessedTime:   df.AccessedTime,
+		ModifiedOffset: df.ModifiedOffset,
+		AccessedOffset: df.AccessedOffset,
 	}
 }
 
@@ -76,44 +162,65 @@ func (df *DirFixture) MakeDir(fp string) dt.DirPath {
 }
 
 // CreateWithParent creates the directory structure and files for this fixture with the specified parent.
-func (df *DirFixture) createWithParent(t *testing.T, pf Fixture) {
-	t.Helper()
+func (df *DirFixture) createWithParent(ctx context.Context, pf Fixture) (err error) {
+	var errs []error
+	var root *RootFixture
+
 	df.created = true
 
 	// Create a single dir directory with .git
 	df.dir = dt.DirPathJoin(pf.Dir(), df.Name)
-	if df.Permissions == 0 {
-		t.Errorf("File permissions not set for %s", df.dir)
-	}
-	err := dt.MkdirAll(df.dir, os.FileMode(df.Permissions))
+	err = checkContext(ctx, df.dir)
 	if err != nil {
-		t.Errorf("Failed to create testing directory %s", df.dir)
+		goto end
 	}
+	root = rootOf(pf)
+	errs = dt.AppendErr(errs, root.mkdirAll(pf.Dir(), string(df.Name), fileMode(df.Permissions)))
+	errs = dt.AppendErr(errs, lchown(dt.EntryPath(df.dir), df.Owner, df.Group))
+	errs = dt.AppendErr(errs, setXAttrs(dt.EntryPath(df.dir), df.XAttrs))
+	root.recordDir(pendingDir{
+		path:     df.dir,
+		mode:     fileMode(df.Permissions),
+		times:    df.times(),
+		declared: true,
+	})
+	root.recordDenial(dt.EntryPath(df.dir), df.Deny)
 	for _, file := range df.FileFixtures {
-		file.Create(t, df)
+		errs = dt.AppendErr(errs, file.create(ctx, df))
 	}
 	for _, child := range df.ChildFixtures {
-		child.createWithParent(t, df)
+		errs = dt.AppendErr(errs, child.createWithParent(ctx, df))
 	}
+	err = dt.CombineErrs(errs)
+end:
+	return err
 }
 
 // AddDirFixture adds a subdirectory fixture to this directory fixture.
-func (df *DirFixture) AddDirFixture(t *testing.T, name dt.PathSegments, args *DirFixtureArgs) *DirFixture {
+func (df *DirFixture) AddDirFixture(t testing.TB, name dt.PathSegments, args *DirFixtureArgs) *DirFixture {
 	cf := newDirFixture(t, name, df, args)
+	validateChild(t, df, "DirFixture", string(name), cf.AllowEscape)
+	registerChild(t, df, "DirFixture", string(name), cf, true, args != nil && args.Override)
+	adoptFiles(t, cf, cf.FileFixtures)
 	df.ChildFixtures = append(df.ChildFixtures, cf)
 	return cf
 }
 
 // AddRepoFixture adds a repository fixture to this directory fixture.
-func (df *DirFixture) AddRepoFixture(t *testing.T, name dt.PathSegments, args *RepoFixtureArgs) *RepoFixture {
+func (df *DirFixture) AddRepoFixture(t testing.TB, name dt.PathSegments, args *RepoFixtureArgs) *RepoFixture {
 	cf := newRepoFixture(t, name, df, args)
+	validateChild(t, df, "RepoFixture", string(name), cf.AllowEscape)
+	registerChild(t, df, "RepoFixture", string(name), cf, true, args != nil && args.Override)
+	adoptFiles(t, cf, cf.FileFixtures)
 	df.ChildFixtures = append(df.ChildFixtures, cf)
 	return cf
 }
 
 // AddFileFixture adds a file fixture to a dir fixture
-func (df *DirFixture) AddFileFixture(t *testing.T, name dt.RelFilepath, args *FileFixtureArgs) *FileFixture {
+func (df *DirFixture) AddFileFixture(t testing.TB, name dt.RelFilepath, args *FileFixtureArgs) *FileFixture {
 	ff := newFileFixture(t, name, df, args)
+	validateChild(t, df, "FileFixture", string(name), ff.AllowEscape)
+	registerChild(t, df, "FileFixture", string(name), ff, false, args != nil && args.Override)
 	df.FileFixtures = append(df.FileFixtures, ff)
 	return ff
 }
@@ -122,7 +229,7 @@ func (df *DirFixture) AddFileFixture(t *testing.T, name dt.RelFilepath, args *Fi
 // FileFixtureArgs when one of args is passed just as a string(string) and it
 // gets its content from ContentFunc, or a FileFixtureArgs is passed which must
 // include Name.
-func (df *DirFixture) AddFileFixtures(t *testing.T, defaults *FileFixtureArgs, args ...any) {
+func (df *DirFixture) AddFileFixtures(t testing.TB, defaults *FileFixtureArgs, args ...any) {
 	for _, f := range args {
 		switch ffa := f.(type) {
 		case dt.Filename:
@@ -131,11 +238,11 @@ func (df *DirFixture) AddFileFixtures(t *testing.T, defaults *FileFixtureArgs, a
 			df.AddFileFixture(t, ffa, defaults)
 		case *FileFixtureArgs:
 			if ffa.Name == "" {
-				t.Fatalf("Name not set for file fixure being added to dir fixture '%s'", df.Name)
+				fatalf(t, "Name not set for file fixure being added to dir fixture '%s'", df.Name)
 			}
 			df.AddFileFixture(t, ffa.Name, ffa)
 		default:
-			t.Fatalf("Invalid type '%T' passed for file fixure being added to dir fixture: '%v'", f, f)
+			fatalf(t, "Invalid type '%T' passed for file fixure being added to dir fixture: '%v'", f, f)
 		}
 	}
 }
@@ -0,0 +1,48 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"errors"
+)
+
+var (
+	ErrFailedToCreateTempDir   = errors.New("failed to create temp directory")
+	ErrFailedToCreateDir       = errors.New("failed to create directory")
+	ErrFailedToCreateFile      = errors.New("failed to create file")
+	ErrFailedToSetTimes        = errors.New("failed to set modification time")
+	ErrFailedToRemoveTempDir   = errors.New("failed to remove temp directory")
+	ErrFixtureBuildCancelled   = errors.New("fixture build cancelled")
+	ErrInvalidFixtureName      = errors.New("invalid fixture name")
+	ErrAbsoluteFixtureName     = errors.New("fixture name must be relative")
+	ErrFixtureNameEscapesRoot  = errors.New("fixture name escapes fixture root")
+	ErrInvalidTimeOffset       = errors.New("invalid time offset")
+	ErrFailedToSetMode         = errors.New("failed to set mode")
+	ErrModeNotHonored          = errors.New("filesystem did not honor requested mode")
+	ErrOwnershipUnsupported    = errors.New("ownership not supported on this platform")
+	ErrInsufficientPrivilege   = errors.New("insufficient privilege")
+	ErrUnknownOwner            = errors.New("unknown owner")
+	ErrUnknownGroup            = errors.New("unknown group")
+	ErrFailedToSetOwnership    = errors.New("failed to set ownership")
+	ErrXAttrsUnsupported       = errors.New("extended attributes not supported")
+	ErrFailedToSetXAttr        = errors.New("failed to set extended attribute")
+	ErrFailedToReadXAttrs      = errors.New("failed to read extended attributes")
+	ErrFileKindUnsupported     = errors.New("file kind not supported on this platform")
+	ErrUnknownFileKind         = errors.New("unknown file kind")
+	ErrDeviceNodesNotPermitted = errors.New("creating device nodes not permitted")
+	ErrSocketPathTooLong       = errors.New("socket path too long")
+	ErrAccessNotDenied         = errors.New("access not denied")
+	ErrLocksUnsupported        = errors.New("file locks not supported on this platform")
+	ErrUnknownLockKind         = errors.New("unknown lock kind")
+	ErrFailedToLockFile        = errors.New("failed to lock file")
+	ErrFailedToHoldOpen        = errors.New("failed to hold file open")
+	ErrLiveWriterFailed        = errors.New("live writer failed")
+	ErrInvalidFixtureJSON      = errors.New("invalid fixture JSON")
+	ErrFuncNotEncoded          = errors.New("function cannot be decoded from fixture JSON")
+	ErrVarNotEncoded           = errors.New("variable cannot be encoded as fixture JSON")
+	ErrUnknownAccessDenial     = errors.New("unknown access denial")
+	ErrUnknownRotationStyle    = errors.New("unknown rotation style")
+	ErrFailedToResetEntry      = errors.New("failed to reset entry")
+	ErrInvalidHelperSpec       = errors.New("invalid helper process spec")
+	ErrHelperProcessFailed     = errors.New("helper process failed")
+)
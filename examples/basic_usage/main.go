@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"testing"
 
 	"github.com/mikeschinkel/go-fsfix"
 )
@@ -17,21 +17,21 @@ func main() {
 	tf := fsfix.NewRootFixture("example-project")
 	defer tf.Cleanup()
 
-	t := &testing.T{}
-
+	// Outside of tests there is no testing.TB, so pass nil when adding
+	// fixtures and call Build() instead of Create() to get errors back.
 	// Add a simple file directly to the root
-	configFile := tf.AddFileFixture(t, "config.json", &fsfix.FileFixtureArgs{
+	configFile := tf.AddFileFixture(nil, "config.json", &fsfix.FileFixtureArgs{
 		Content: `{"app": "example", "version": "1.0"}`,
 	})
 
 	// Add a repository-like structure
-	repoFixture := tf.AddRepoFixture(t, "myapp", nil)
+	repoFixture := tf.AddRepoFixture(nil, "myapp", nil)
 
 	// Add a directory within the repo
-	srcDir := repoFixture.AddDirFixture(t, "src", nil)
+	srcDir := repoFixture.AddDirFixture(nil, "src", nil)
 
 	// Add files to the src directory
-	mainFile := srcDir.AddFileFixture(t, "main.go", &fsfix.FileFixtureArgs{
+	mainFile := srcDir.AddFileFixture(nil, "main.go", &fsfix.FileFixtureArgs{
 		Content: `package main
 
 func main() {
@@ -39,7 +39,7 @@ func main() {
 }`,
 	})
 
-	utilFile := srcDir.AddFileFixture(t, "util.go", &fsfix.FileFixtureArgs{
+	utilFile := srcDir.AddFileFixture(nil, "util.go", &fsfix.FileFixtureArgs{
 		Content: `package main
 
 func helper() string {
@@ -48,8 +48,8 @@ func helper() string {
 	})
 
 	// Add a test directory
-	testDir := repoFixture.AddDirFixture(t, "test", nil)
-	testDir.AddFileFixture(t, "main_test.go", &fsfix.FileFixtureArgs{
+	testDir := repoFixture.AddDirFixture(nil, "test", nil)
+	testDir.AddFileFixture(nil, "main_test.go", &fsfix.FileFixtureArgs{
 		Content: `package main
 
 import "testing"
@@ -60,7 +60,11 @@ func TestExample(t *testing.T) {
 	})
 
 	// Create all the fixtures (actually write files to disk)
-	tf.Create(t)
+	err := tf.Build(context.Background())
+	if err != nil {
+		fmt.Printf("Error building fixtures: %v\n", err)
+		return
+	}
 
 	// Display the created structure
 	fmt.Println("Created temporary test structure:")
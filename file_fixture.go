@@ -3,7 +3,11 @@
 package fsfix
 
 import (
+	"context"
+	"crypto/sha256"
+	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -14,15 +18,37 @@ import (
 type FileFixture struct {
 	Filepath       dt.Filepath
 	Name           dt.RelFilepath
+	Kind           FileKind
 	Content        string
 	ContentFunc    ContentFunc
+	Vars           map[string]any
+	DeviceMajor    uint32
+	DeviceMinor    uint32
 	Permissions    int
 	DirPermissions int
+	Deny           AccessDenial
+	Owner          string
+	Group          string
+	XAttrs         map[string][]byte
 	ModifiedTime   time.Time
+	AccessedTime   time.Time
+	ModifiedOffset string
+	AccessedOffset string
+	Lock           *FileLockArgs
+	HoldOpen       *HoldOpenArgs
+	Writer         *LiveWriterArgs
 	DoNotCreate    bool
+	AllowEscape    bool
 	Parent         Fixture
+	liveWriter     *LiveWriter
+	lock           *heldResource
+	held           *heldResource
+	handle         *os.File
+	evaluated      bool              // ContentFunc has been called to set Content
+	stamp          fileStamp         // Identifies the content as created, so Reset() can skip unchanged files unread
+	sum            [sha256.Size]byte // Hash of the content as created, for Reset()
 	created        bool
-	t              *testing.T
+	t              testing.TB
 }
 
 type ContentFunc func(ff *FileFixture) string
@@ -30,36 +56,130 @@ type ContentFunc func(ff *FileFixture) string
 // FileFixtureArgs contains arguments for creating a FileFixture.
 type FileFixtureArgs struct {
 	Name           dt.RelFilepath
+	Kind           FileKind // Type of entry to create; Content only applies to regular files
 	Content        string
 	ContentFunc    ContentFunc
+	Vars           map[string]any // Variables for ContentFunc, e.g. those of a template Mount()
+	DeviceMajor    uint32         // Major device number for CharDeviceKind and BlockDeviceKind
+	DeviceMinor    uint32         // Minor device number for CharDeviceKind and BlockDeviceKind
 	ModifiedTime   time.Time
+	AccessedTime   time.Time // Defaults to the modification time
+	ModifiedOffset string    // Offset from the RootFixture's BaseTime, e.g. "-72h"
+	AccessedOffset string    // Offset from the RootFixture's BaseTime, e.g. "-72h"
 	Permissions    int
 	DirPermissions int
+	Deny           AccessDenial      // Preset denial, e.g. UnreadableFile; replaces Permissions, skips the test as root
+	Owner          string            // Owner to lchown to, numeric or by name; skips the test if not permitted
+	Group          string            // Group to lchown to, numeric or by name; skips the test if not permitted
+	XAttrs         map[string][]byte // Extended attributes, e.g. "user.origin"; skips the test if unsupported
+	Lock           *FileLockArgs     // Advisory lock to hold from creation until released or cleaned up
+	HoldOpen       *HoldOpenArgs     // Handle to keep open from creation until released or cleaned up
+	Writer         *LiveWriterArgs   // Keep appending to the file once the tree is created, until stopped or cleaned up
 	DoNotCreate    bool
+	AllowEscape    bool // Permit Name to resolve outside the fixture root
+	Override       bool // Intentionally replace an earlier fixture declaring the same path
+}
+
+// applyPermissionDefaults gives zero Permissions and DirPermissions their
+// defaults of 0644 and 0755, and applies any Deny preset over Permissions.
+func (ff *FileFixture) applyPermissionDefaults() {
+	if ff.Permissions == 0 {
+		ff.Permissions = 0644
+	}
+	if ff.DirPermissions == 0 {
+		ff.DirPermissions = 0755
+	}
+	if ff.Deny != NoDenial {
+		ff.Permissions = ff.Deny.permissions()
+	}
 }
 
 // newFileFixture creates a new file fixture with the specified name and arguments.
-func newFileFixture(t *testing.T, name dt.RelFilepath, parent Fixture, args *FileFixtureArgs) *FileFixture {
+func newFileFixture(t testing.TB, name dt.RelFilepath, parent Fixture, args *FileFixtureArgs) *FileFixture {
 	if args == nil {
 		args = &FileFixtureArgs{}
 	}
-	if args.Permissions == 0 {
-		args.Permissions = 0644
-	}
-	if args.DirPermissions == 0 {
-		args.DirPermissions = 0755
-	}
-	return &FileFixture{
+	ff := &FileFixture{
 		Name:           name,
 		Parent:         parent,
+		Kind:           args.Kind,
 		Content:        args.Content,
 		ContentFunc:    args.ContentFunc,
+		Vars:           args.Vars,
+		DeviceMajor:    args.DeviceMajor,
+		DeviceMinor:    args.DeviceMinor,
 		Permissions:    args.Permissions,
 		DirPermissions: args.DirPermissions,
+		Deny:           args.Deny,
+		Owner:          args.Owner,
+		Group:          args.Group,
+		XAttrs:         args.XAttrs,
 		ModifiedTime:   args.ModifiedTime,
+		AccessedTime:   args.AccessedTime,
+		ModifiedOffset: args.ModifiedOffset,
+		AccessedOffset: args.AccessedOffset,
+		Lock:           args.Lock,
+		HoldOpen:       args.HoldOpen,
+		Writer:         args.Writer,
 		DoNotCreate:    args.DoNotCreate,
+		AllowEscape:    args.AllowEscape,
 		t:              t,
 	}
+	err := ff.times().validateOffsets()
+	if err != nil {
+		fatalf(t, "Invalid time offset for file fixture '%s'; %v", name, err)
+	}
+	ff.applyPermissionDefaults()
+	requireOSBackend(t, parent, "file fixture '"+string(name)+"'", ff.osOnlyFeature())
+	checkDenial(t, "file fixture '"+string(name)+"'", ff.Deny, false)
+	skipUnlessCanChown(t, "file fixture '"+string(name)+"'", ff.Owner, ff.Group)
+	return ff
+}
+
+// osOnlyFeature names the first feature the file fixture uses that needs
+// the OS backend, or returns "".
+func (ff *FileFixture) osOnlyFeature() string {
+	switch {
+	case ff.Owner != "" || ff.Group != "":
+		return "Owner or Group"
+	case len(ff.XAttrs) > 0:
+		return "XAttrs"
+	case ff.Kind != RegularFileKind:
+		return ff.Kind.String() + " Kind"
+	case ff.Deny != NoDenial:
+		return "Deny"
+	case ff.Lock != nil && ff.Lock.Kind != NoLock:
+		return "Lock"
+	case ff.HoldOpen != nil:
+		return "HoldOpen"
+	}
+	return ""
+}
+
+// times returns the timestamps declared for this file fixture.
+func (ff *FileFixture) times() fixtureTimes {
+	return fixtureTimes{
+		ModifiedTime:   ff.ModifiedTime,
+		AccessedTime:   ff.AccessedTime,
+		ModifiedOffset: ff.ModifiedOffset,
+		AccessedOffset: ff.AccessedOffset,
+	}
+}
+
+// ensureCreated forces a failure if called before Create() is called.
+func (ff *FileFixture) ensureCreated(t testing.TB) {
+	t.Helper()
+	if !ff.created {
+		fatalf(t, "FileFixture '%s' has not yet been created", ff.Name)
+	}
+}
+
+func (ff *FileFixture) parentFixture() Fixture {
+	return ff.Parent
+}
+
+func (ff *FileFixture) fixtureLabel() string {
+	return fmt.Sprintf("FileFixture '%s'", ff.Name)
 }
 
 func (ff *FileFixture) RelativePath() dt.Filepath {
@@ -67,47 +187,114 @@ func (ff *FileFixture) RelativePath() dt.Filepath {
 }
 
 // Create creates the file within the specified parent fixture's directory.
-func (ff *FileFixture) Create(t *testing.T, pf Fixture) {
+func (ff *FileFixture) Create(t testing.TB, pf Fixture) {
 	t.Helper()
-	ff.created = true
 	ff.Parent = pf
+	err := ff.create(context.Background(), pf)
+	if err == nil {
+		err = rootOf(pf).finalizeDirs()
+	}
+	if err == nil {
+		err = rootOf(pf).verifyDenials()
+	}
+	if err == nil {
+		rootOf(pf).startWriters()
+	}
+	skipIfUnsupported(t, ff.fixtureLabel(), err)
+	if err != nil {
+		t.Errorf("Failed to create file fixture '%s'; %v", ff.Name, err)
+	}
+}
+
+// create creates the file within the specified parent fixture's directory,
+// returning any failure rather than reporting it to a testing.TB.
+func (ff *FileFixture) create(ctx context.Context, pf Fixture) error {
+	ff.created = true
+	// Keep the parent the fixture was added to; a RepoFixture creates its
+	// files via its embedded DirFixture, which would double its name in
+	// RelativePath()
+	if ff.Parent == nil {
+		ff.Parent = pf
+	}
 	ff.Filepath = dt.FilepathJoin(pf.Dir(), ff.Name)
-	ff.createFile(t)
+	err := checkContext(ctx, ff.Filepath)
+	if err != nil {
+		return err
+	}
+	return ff.createFile()
 }
 
 // createFile handles the common file creation logic
-func (ff *FileFixture) createFile(t *testing.T) {
-	var err error
-	t.Helper()
+func (ff *FileFixture) createFile() (err error) {
+	var errs []error
+
 	// Skip file creation if it's marked as DoNotCreate
 	if ff.DoNotCreate {
 		goto end
 	}
 
-	if ff.Permissions == 0 {
-		t.Errorf("File permissions not set for %s", ff.Filepath)
-	}
+	// Intermediate directories get DirPermissions exactly, once their
+	// contents have been created
+	errs = dt.AppendErr(errs, rootOf(ff.Parent).mkdirAll(ff.Parent.Dir(), filepath.Dir(string(ff.Name)), fileMode(ff.DirPermissions)))
 
-	err = ff.Filepath.Dir().MkdirAll(os.FileMode(ff.DirPermissions))
-	if err != nil {
-		t.Errorf("Failed to create test file directory %s", ff.Filepath.Dir())
+	if ff.Kind != RegularFileKind {
+		err = ff.createSpecial()
+		if err != nil {
+			errs = append(errs, err)
+			goto end
+		}
+		goto created
 	}
 
-	if ff.ContentFunc != nil {
+	if ff.ContentFunc != nil && !ff.evaluated {
 		ff.Content = ff.ContentFunc(ff)
+		ff.evaluated = true
 	}
 
-	err = dt.WriteFile(ff.Filepath, []byte(ff.Content), os.FileMode(ff.Permissions))
+	// Created owner-writable so attributes can be set before the final mode
+	err = rootOf(ff.Parent).writeContent(ff, fileMode(ff.Permissions).Perm()|0200)
 	if err != nil {
-		t.Errorf("Failed to create test file %s", ff.Filepath)
+		errs = append(errs, dt.NewErr(ErrFailedToCreateFile, "path", ff.Filepath, err))
+		goto end
 	}
 
-	// Set modification time if specified
-	if !ff.ModifiedTime.IsZero() {
-		err = dt.ChangeFileTimes(ff.Filepath, ff.ModifiedTime, ff.ModifiedTime)
-		if err != nil {
-			t.Errorf("Failed to set modification time for %s", ff.Filepath)
-		}
+created:
+	errs = dt.AppendErr(errs, ff.applyAttributes())
+	rootOf(ff.Parent).recordDenial(dt.EntryPath(ff.Filepath), ff.Deny)
+	ff.recordSum()
+	ff.recordStamp()
+
+	// Locking last means the lock is held on the file in its final state
+	if ff.Lock != nil && ff.Lock.Kind != NoLock && len(errs) == 0 {
+		errs = dt.AppendErr(errs, ff.acquireLock())
+	}
+	if ff.HoldOpen != nil && len(errs) == 0 {
+		errs = dt.AppendErr(errs, ff.holdOpen())
+	}
+	if ff.Writer != nil && len(errs) == 0 {
+		ff.liveWriter = newLiveWriter(rootOf(ff.Parent).Backend(), ff.Filepath, fileMode(ff.Permissions).Perm(), *ff.Writer)
+		rootOf(ff.Parent).recordWriter(ff.liveWriter)
 	}
 end:
+	return dt.CombineErrs(errs)
+}
+
+// applyAttributes applies the declared ownership, extended attributes, mode
+// and times to the newly written file.
+func (ff *FileFixture) applyAttributes() error {
+	var errs []error
+
+	// Ownership goes before the mode as chown clears setuid and setgid bits
+	errs = dt.AppendErr(errs, lchown(dt.EntryPath(ff.Filepath), ff.Owner, ff.Group))
+
+	// Extended attributes go before the mode as a read-only file rejects them
+	errs = dt.AppendErr(errs, setXAttrs(dt.EntryPath(ff.Filepath), ff.XAttrs))
+
+	// Apply the exact mode as WriteFile's is filtered by the umask
+	errs = dt.AppendErr(errs, chmodExact(rootOf(ff.Parent).Backend(), dt.EntryPath(ff.Filepath), fileMode(ff.Permissions)))
+
+	// Set access and modification times if specified
+	errs = dt.AppendErr(errs, clockOf(ff.Parent).apply(rootOf(ff.Parent).Backend(), dt.EntryPath(ff.Filepath), ff.times()))
+
+	return dt.CombineErrs(errs)
 }
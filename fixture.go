@@ -3,6 +3,9 @@
 package fsfix
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/mikeschinkel/go-dt"
@@ -12,5 +15,46 @@ import (
 type Fixture interface {
 	Dir() dt.DirPath
 	RelativePath() dt.DirPath
-	createWithParent(*testing.T, Fixture)
+	createWithParent(context.Context, Fixture) error
+	parentFixture() Fixture
+	fixtureLabel() string
+}
+
+// fatalf reports a fatal fixture misuse via t, or panics when the fixture is
+// being used without a testing.TB, e.g. from RootFixture.Build().
+func fatalf(t testing.TB, format string, args ...any) {
+	if t == nil {
+		panic(fmt.Sprintf(format, args...))
+	}
+	t.Helper()
+	t.Fatalf(format, args...)
+}
+
+// skippableErrs are errors meaning the environment cannot provide what a
+// fixture needs, so Create() skips the test rather than failing it.
+var skippableErrs = []error{
+	ErrXAttrsUnsupported,
+	ErrFileKindUnsupported,
+	ErrDeviceNodesNotPermitted,
+	ErrAccessNotDenied,
+	ErrLocksUnsupported,
+}
+
+// skipIfUnsupported skips the test if err means the environment cannot
+// provide what the fixture labelled label needs.
+func skipIfUnsupported(t testing.TB, label string, err error) {
+	t.Helper()
+	for _, skippable := range skippableErrs {
+		if errors.Is(err, skippable) {
+			t.Skipf("Skipping as %s cannot be created here; %v", label, err)
+		}
+	}
+}
+
+// checkContext returns an error with path context if ctx has been cancelled.
+func checkContext(ctx context.Context, path any) (err error) {
+	if ctx.Err() != nil {
+		err = dt.NewErr(ErrFixtureBuildCancelled, "path", path, ctx.Err())
+	}
+	return err
 }
@@ -0,0 +1,86 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"io/fs"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// _ is a compile-time check that FixtureFS implements the optional io/fs
+// interfaces callers commonly depend on.
+var (
+	_ fs.StatFS     = (*FixtureFS)(nil)
+	_ fs.ReadDirFS  = (*FixtureFS)(nil)
+	_ fs.ReadFileFS = (*FixtureFS)(nil)
+	_ fs.SubFS      = (*FixtureFS)(nil)
+)
+
+// FixtureFS is an fs.FS rooted at a created fixture's directory, so
+// fixtures can be passed straight to APIs that accept an fs.FS.
+type FixtureFS struct {
+	backend Backend
+	dir     dt.DirPath
+	fsys    fs.FS
+}
+
+// newFixtureFS returns a FixtureFS rooted at dir in b.
+func newFixtureFS(b Backend, dir dt.DirPath) *FixtureFS {
+	return &FixtureFS{
+		backend: b,
+		dir:     dir,
+		fsys:    b.DirFS(string(dir)),
+	}
+}
+
+// Dir returns the directory the FixtureFS is rooted at.
+func (ffs *FixtureFS) Dir() dt.DirPath {
+	return ffs.dir
+}
+
+// Open opens the named file.
+func (ffs *FixtureFS) Open(name string) (fs.File, error) {
+	return ffs.fsys.Open(name)
+}
+
+// Stat returns a FileInfo describing the named file.
+func (ffs *FixtureFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(ffs.fsys, name)
+}
+
+// ReadDir reads the named directory, returning its entries sorted by filename.
+func (ffs *FixtureFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(ffs.fsys, name)
+}
+
+// ReadFile reads the named file and returns its contents.
+func (ffs *FixtureFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(ffs.fsys, name)
+}
+
+// Sub returns a FixtureFS rooted at the named subdirectory.
+func (ffs *FixtureFS) Sub(dir string) (fs.FS, error) {
+	if !fs.ValidPath(dir) {
+		return nil, &fs.PathError{Op: "sub", Path: dir, Err: fs.ErrInvalid}
+	}
+	if dir == "." {
+		return ffs, nil
+	}
+	return newFixtureFS(ffs.backend, dt.DirPathJoin(ffs.dir, dir)), nil
+}
+
+// FS returns an fs.FS rooted at the fixture's temporary directory.
+func (rf *RootFixture) FS() *FixtureFS {
+	return newFixtureFS(rf.Backend(), rf.Dir())
+}
+
+// FS returns an fs.FS rooted at the directory fixture.
+func (df *DirFixture) FS() *FixtureFS {
+	return newFixtureFS(rootOf(df).Backend(), df.Dir())
+}
+
+// FS returns an fs.FS rooted at the repository fixture.
+func (rf *RepoFixture) FS() *FixtureFS {
+	return newFixtureFS(rootOf(rf).Backend(), rf.Dir())
+}
@@ -0,0 +1,549 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+const (
+	dirFixtureType  = "dir"
+	repoFixtureType = "repo"
+)
+
+// rootFixtureJSON is the JSON encoding of a RootFixture's declared state.
+type rootFixtureJSON struct {
+	DirPrefix string            `json:"dirPrefix"`
+	BaseDir   dt.DirPath        `json:"baseDir,omitempty"`
+	BaseTime  time.Time         `json:"baseTime,omitzero"`
+	Files     []fileFixtureJSON `json:"files,omitempty"`
+	Children  []json.RawMessage `json:"children,omitempty"`
+}
+
+// dirFixtureJSON is the JSON encoding of a DirFixture's or RepoFixture's
+// declared state, distinguished by Type.
+type dirFixtureJSON struct {
+	Type           string            `json:"type"`
+	Name           dt.PathSegments   `json:"name"`
+	Permissions    *octalPermissions `json:"permissions,omitempty"` // Nil for the default; set even for 0000
+	Deny           AccessDenial      `json:"deny,omitempty"`
+	Owner          string            `json:"owner,omitempty"`
+	Group          string            `json:"group,omitempty"`
+	XAttrs         map[string][]byte `json:"xattrs,omitempty"`
+	ModifiedTime   time.Time         `json:"modifiedTime,omitzero"`
+	AccessedTime   time.Time         `json:"accessedTime,omitzero"`
+	ModifiedOffset string            `json:"modifiedOffset,omitempty"`
+	AccessedOffset string            `json:"accessedOffset,omitempty"`
+	AllowEscape    bool              `json:"allowEscape,omitempty"`
+	Files          []fileFixtureJSON `json:"files,omitempty"`
+	Children       []json.RawMessage `json:"children,omitempty"`
+}
+
+// fileFixtureJSON is the JSON encoding of a FileFixture's declared state.
+type fileFixtureJSON struct {
+	Name           dt.RelFilepath             `json:"name"`
+	Kind           FileKind                   `json:"kind,omitempty"`
+	Content        string                     `json:"content,omitempty"`
+	ContentFunc    bool                       `json:"contentFunc,omitempty"` // ContentFunc is set but not yet evaluated
+	Vars           map[string]json.RawMessage `json:"vars,omitempty"`
+	DeviceMajor    uint32                     `json:"deviceMajor,omitempty"`
+	DeviceMinor    uint32                     `json:"deviceMinor,omitempty"`
+	Permissions    *octalPermissions          `json:"permissions,omitempty"`    // Nil for the default; set even for 0000
+	DirPermissions *octalPermissions          `json:"dirPermissions,omitempty"` // Nil for the default; set even for 0000
+	Deny           AccessDenial               `json:"deny,omitempty"`
+	Owner          string                     `json:"owner,omitempty"`
+	Group          string                     `json:"group,omitempty"`
+	XAttrs         map[string][]byte          `json:"xattrs,omitempty"`
+	ModifiedTime   time.Time                  `json:"modifiedTime,omitzero"`
+	AccessedTime   time.Time                  `json:"accessedTime,omitzero"`
+	ModifiedOffset string                     `json:"modifiedOffset,omitempty"`
+	AccessedOffset string                     `json:"accessedOffset,omitempty"`
+	Lock           *fileLockJSON              `json:"lock,omitempty"`
+	HoldOpen       *holdOpenJSON              `json:"holdOpen,omitempty"`
+	Writer         *liveWriterJSON            `json:"writer,omitempty"`
+	DoNotCreate    bool                       `json:"doNotCreate,omitempty"`
+	AllowEscape    bool                       `json:"allowEscape,omitempty"`
+}
+
+// fileLockJSON is the JSON encoding of FileLockArgs.
+type fileLockJSON struct {
+	Kind            LockKind `json:"kind"`
+	Start           int64    `json:"start,omitempty"`
+	Len             int64    `json:"len,omitempty"`
+	InHelperProcess bool     `json:"inHelperProcess,omitempty"`
+}
+
+// holdOpenJSON is the JSON encoding of HoldOpenArgs.
+type holdOpenJSON struct {
+	Writable        bool `json:"writable,omitempty"`
+	InHelperProcess bool `json:"inHelperProcess,omitempty"`
+}
+
+// liveWriterJSON is the JSON encoding of LiveWriterArgs.
+type liveWriterJSON struct {
+	Interval      string        `json:"interval,omitempty"`
+	DataFunc      bool          `json:"dataFunc,omitempty"` // Data is set, so cannot be encoded
+	Count         int           `json:"count,omitempty"`
+	TruncateEvery int           `json:"truncateEvery,omitempty"`
+	RotateEvery   int           `json:"rotateEvery,omitempty"`
+	Rotation      RotationStyle `json:"rotation,omitempty"`
+}
+
+// octalPermissions encodes permission bits as an octal string, e.g. "0644".
+type octalPermissions int
+
+func (p octalPermissions) MarshalText() ([]byte, error) {
+	return fmt.Appendf(nil, "%04o", int(p)), nil
+}
+
+func (p *octalPermissions) UnmarshalText(text []byte) error {
+	n, err := strconv.ParseInt(string(text), 8, 32)
+	if err != nil {
+		return dt.NewErr(ErrInvalidFixtureJSON, "permissions", string(text), err)
+	}
+	*p = octalPermissions(n)
+	return nil
+}
+
+// octalOf returns perm to encode, as a pointer so that 0000 is not omitted.
+func octalOf(perm int) *octalPermissions {
+	p := octalPermissions(perm)
+	return &p
+}
+
+// value returns the decoded permissions, or zero for the default if none
+// were encoded.
+func (p *octalPermissions) value() int {
+	if p == nil {
+		return 0
+	}
+	return int(*p)
+}
+
+// restore sets *perm to the decoded permissions if any were encoded,
+// including 0000, which args cannot express as it means the default there.
+func (p *octalPermissions) restore(perm *int) {
+	if p != nil {
+		*perm = int(*p)
+	}
+}
+
+// enum is implemented by the enumerated types encoded by their String().
+type enum interface {
+	~int
+	String() string
+}
+
+// parseEnum returns the value from first to last whose String() is text.
+func parseEnum[T enum](text []byte, first, last T, unknown error) (v T, err error) {
+	for v = first; v <= last; v++ {
+		if v.String() == string(text) {
+			goto end
+		}
+	}
+	err = dt.NewErr(unknown, "value", string(text))
+end:
+	return v, err
+}
+
+func (k FileKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *FileKind) UnmarshalText(text []byte) (err error) {
+	*k, err = parseEnum(text, RegularFileKind, BlockDeviceKind, ErrUnknownFileKind)
+	return err
+}
+
+func (d AccessDenial) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *AccessDenial) UnmarshalText(text []byte) (err error) {
+	*d, err = parseEnum(text, NoDenial, ReadOnlyDir, ErrUnknownAccessDenial)
+	return err
+}
+
+func (k LockKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *LockKind) UnmarshalText(text []byte) (err error) {
+	*k, err = parseEnum(text, NoLock, WriteRangeLock, ErrUnknownLockKind)
+	return err
+}
+
+func (s RotationStyle) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *RotationStyle) UnmarshalText(text []byte) (err error) {
+	*s, err = parseEnum(text, RenameRotation, CopyTruncateRotation, ErrUnknownRotationStyle)
+	return err
+}
+
+// MarshalJSON encodes the declared state of the fixture tree with stable
+// field order, so encodings can be stored as test artifacts and diffed.
+// Content produced by a ContentFunc is included once Create() has evaluated
+// it; until then only the fact that a ContentFunc is set is recorded. Vars
+// are encoded as JSON values, so decode as the types encoding/json produces,
+// e.g. float64 for numbers, and Vars that cannot be encoded return
+// ErrVarNotEncoded. The Backend is not encoded.
+func (rf *RootFixture) MarshalJSON() ([]byte, error) {
+	files, err := filesJSON(rf.FileFixtures)
+	if err != nil {
+		return nil, err
+	}
+	children, err := childrenJSON(rf.ChildFixtures)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(rootFixtureJSON{
+		DirPrefix: rf.DirPrefix,
+		BaseDir:   rf.BaseDir,
+		BaseTime:  rf.BaseTime,
+		Files:     files,
+		Children:  children,
+	})
+}
+
+// MarshalJSON encodes the declared state of the directory and everything
+// declared beneath it. See RootFixture.MarshalJSON().
+func (df *DirFixture) MarshalJSON() ([]byte, error) {
+	return df.marshalJSON(dirFixtureType)
+}
+
+// MarshalJSON encodes the declared state of the repository and everything
+// declared beneath it. See RootFixture.MarshalJSON().
+func (rf *RepoFixture) MarshalJSON() ([]byte, error) {
+	return rf.DirFixture.marshalJSON(repoFixtureType)
+}
+
+// marshalJSON encodes the directory as fixtureType.
+func (df *DirFixture) marshalJSON(fixtureType string) ([]byte, error) {
+	files, err := filesJSON(df.FileFixtures)
+	if err != nil {
+		return nil, err
+	}
+	children, err := childrenJSON(df.ChildFixtures)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(dirFixtureJSON{
+		Type:           fixtureType,
+		Name:           df.Name,
+		Permissions:    octalOf(df.Permissions),
+		Deny:           df.Deny,
+		Owner:          df.Owner,
+		Group:          df.Group,
+		XAttrs:         df.XAttrs,
+		ModifiedTime:   df.ModifiedTime,
+		AccessedTime:   df.AccessedTime,
+		ModifiedOffset: df.ModifiedOffset,
+		AccessedOffset: df.AccessedOffset,
+		AllowEscape:    df.AllowEscape,
+		Files:          files,
+		Children:       children,
+	})
+}
+
+// MarshalJSON encodes the declared state of the file. See
+// RootFixture.MarshalJSON().
+func (ff *FileFixture) MarshalJSON() ([]byte, error) {
+	fj, err := ff.toJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(fj)
+}
+
+// toJSON returns the JSON encoding of the file's declared state, or an
+// error if any of its Vars cannot be encoded.
+func (ff *FileFixture) toJSON() (fj fileFixtureJSON, err error) {
+	var raw []byte
+
+	fj = fileFixtureJSON{
+		Name:           ff.Name,
+		Kind:           ff.Kind,
+		Content:        ff.Content,
+		DeviceMajor:    ff.DeviceMajor,
+		DeviceMinor:    ff.DeviceMinor,
+		Permissions:    octalOf(ff.Permissions),
+		DirPermissions: octalOf(ff.DirPermissions),
+		Deny:           ff.Deny,
+		Owner:          ff.Owner,
+		Group:          ff.Group,
+		XAttrs:         ff.XAttrs,
+		ModifiedTime:   ff.ModifiedTime,
+		AccessedTime:   ff.AccessedTime,
+		ModifiedOffset: ff.ModifiedOffset,
+		AccessedOffset: ff.AccessedOffset,
+		DoNotCreate:    ff.DoNotCreate,
+		AllowEscape:    ff.AllowEscape,
+	}
+	if ff.ContentFunc != nil && !ff.evaluated {
+		fj.ContentFunc = true
+	}
+	for name, v := range ff.Vars {
+		raw, err = json.Marshal(v)
+		if err != nil {
+			err = dt.NewErr(ErrVarNotEncoded, "name", ff.Name, "var", name, err)
+			goto end
+		}
+		if fj.Vars == nil {
+			fj.Vars = make(map[string]json.RawMessage, len(ff.Vars))
+		}
+		fj.Vars[name] = raw
+	}
+	if ff.Lock != nil {
+		fj.Lock = &fileLockJSON{
+			Kind:            ff.Lock.Kind,
+			Start:           ff.Lock.Start,
+			Len:             ff.Lock.Len,
+			InHelperProcess: ff.Lock.InHelperProcess,
+		}
+	}
+	if ff.HoldOpen != nil {
+		fj.HoldOpen = &holdOpenJSON{
+			Writable:        ff.HoldOpen.Writable,
+			InHelperProcess: ff.HoldOpen.InHelperProcess,
+		}
+	}
+	if ff.Writer != nil {
+		fj.Writer = &liveWriterJSON{
+			DataFunc:      ff.Writer.Data != nil,
+			Count:         ff.Writer.Count,
+			TruncateEvery: ff.Writer.TruncateEvery,
+			RotateEvery:   ff.Writer.RotateEvery,
+			Rotation:      ff.Writer.Rotation,
+		}
+		if ff.Writer.Interval != 0 {
+			fj.Writer.Interval = ff.Writer.Interval.String()
+		}
+	}
+end:
+	return fj, err
+}
+
+// filesJSON returns the JSON encodings of files.
+func filesJSON(files []*FileFixture) (fjs []fileFixtureJSON, err error) {
+	fjs = make([]fileFixtureJSON, len(files))
+	for i, ff := range files {
+		fjs[i], err = ff.toJSON()
+		if err != nil {
+			goto end
+		}
+	}
+end:
+	return fjs, err
+}
+
+// childrenJSON returns the JSON encodings of child fixtures.
+func childrenJSON(children []Fixture) (raws []json.RawMessage, err error) {
+	var raw []byte
+
+	for _, cf := range children {
+		raw, err = json.Marshal(cf)
+		if err != nil {
+			goto end
+		}
+		raws = append(raws, raw)
+	}
+end:
+	return raws, err
+}
+
+// fixtureAdder is implemented by the fixtures a decoded tree is added to.
+type fixtureAdder interface {
+	Fixture
+	AddDirFixture(t testing.TB, name dt.PathSegments, args *DirFixtureArgs) *DirFixture
+	AddRepoFixture(t testing.TB, name dt.PathSegments, args *RepoFixtureArgs) *RepoFixture
+	AddFileFixture(t testing.TB, name dt.RelFilepath, args *FileFixtureArgs) *FileFixture
+}
+
+// NewRootFixtureFromJSON declares an uncreated RootFixture equivalent to the
+// one encoded in data by RootFixture.MarshalJSON(). args supplies what is not
+// encoded, i.e. Backend, and BaseDir or BaseTime where data does not set
+// them. Fixtures whose ContentFunc had not been evaluated, or whose live
+// writer had a Data func, cannot be reconstructed so return an error, as
+// does malformed JSON. As when adding fixtures, misuse such as duplicate
+// paths is reported via t.
+func NewRootFixtureFromJSON(t testing.TB, data []byte, args *RootFixtureArgs) (rf *RootFixture, err error) {
+	var rj rootFixtureJSON
+	var rootArgs RootFixtureArgs
+
+	err = json.Unmarshal(data, &rj)
+	if err != nil {
+		err = dt.NewErr(ErrInvalidFixtureJSON, err)
+		goto end
+	}
+	if args != nil {
+		rootArgs = *args
+	}
+	if rj.BaseDir != "" {
+		rootArgs.BaseDir = rj.BaseDir
+	}
+	if !rj.BaseTime.IsZero() {
+		rootArgs.BaseTime = rj.BaseTime
+	}
+	rf = NewRootFixtureWithArgs(rj.DirPrefix, &rootArgs)
+	err = decodeFixtures(t, rf, rj.Files, rj.Children)
+	if err != nil {
+		rf = nil
+	}
+end:
+	return rf, err
+}
+
+// decodeFixtures adds the encoded files and child fixtures to parent.
+func decodeFixtures(t testing.TB, parent fixtureAdder, files []fileFixtureJSON, children []json.RawMessage) (err error) {
+	var args *FileFixtureArgs
+
+	for _, fj := range files {
+		args, err = fj.args(parent)
+		if err != nil {
+			goto end
+		}
+		ff := parent.AddFileFixture(t, fj.Name, args)
+		fj.Permissions.restore(&ff.Permissions)
+		fj.DirPermissions.restore(&ff.DirPermissions)
+	}
+	for _, raw := range children {
+		err = decodeChild(t, parent, raw)
+		if err != nil {
+			goto end
+		}
+	}
+end:
+	return err
+}
+
+// decodeChild adds the encoded directory or repository fixture to parent.
+func decodeChild(t testing.TB, parent fixtureAdder, raw json.RawMessage) (err error) {
+	var dj dirFixtureJSON
+	var child fixtureAdder
+	var df *DirFixture
+
+	err = json.Unmarshal(raw, &dj)
+	if err != nil {
+		err = dt.NewErr(ErrInvalidFixtureJSON, "parent", parent.RelativePath(), err)
+		goto end
+	}
+	switch dj.Type {
+	case dirFixtureType:
+		df = parent.AddDirFixture(t, dj.Name, &DirFixtureArgs{
+			Permissions:    dj.Permissions.value(),
+			Deny:           dj.Deny,
+			Owner:          dj.Owner,
+			Group:          dj.Group,
+			XAttrs:         dj.XAttrs,
+			ModifiedTime:   dj.ModifiedTime,
+			AccessedTime:   dj.AccessedTime,
+			ModifiedOffset: dj.ModifiedOffset,
+			AccessedOffset: dj.AccessedOffset,
+			AllowEscape:    dj.AllowEscape,
+		})
+		child = df
+	case repoFixtureType:
+		repo := parent.AddRepoFixture(t, dj.Name, &RepoFixtureArgs{
+			Permissions:    dj.Permissions.value(),
+			ModifiedTime:   dj.ModifiedTime,
+			AccessedTime:   dj.AccessedTime,
+			ModifiedOffset: dj.ModifiedOffset,
+			AccessedOffset: dj.AccessedOffset,
+			AllowEscape:    dj.AllowEscape,
+		})
+		df, child = repo.DirFixture, repo
+	default:
+		err = dt.NewErr(ErrInvalidFixtureJSON, "name", dj.Name, "type", dj.Type)
+		goto end
+	}
+	dj.Permissions.restore(&df.Permissions)
+	err = decodeFixtures(t, child, dj.Files, dj.Children)
+end:
+	return err
+}
+
+// args returns the FileFixtureArgs that reconstruct the encoded file in parent.
+func (fj fileFixtureJSON) args(parent Fixture) (args *FileFixtureArgs, err error) {
+	var interval time.Duration
+	path := dt.FilepathJoin(parent.RelativePath(), fj.Name)
+
+	if fj.ContentFunc {
+		err = dt.NewErr(ErrFuncNotEncoded, "path", path, "field", "ContentFunc")
+		goto end
+	}
+	args = &FileFixtureArgs{
+		Kind:           fj.Kind,
+		Content:        fj.Content,
+		DeviceMajor:    fj.DeviceMajor,
+		DeviceMinor:    fj.DeviceMinor,
+		Permissions:    fj.Permissions.value(),
+		DirPermissions: fj.DirPermissions.value(),
+		Deny:           fj.Deny,
+		Owner:          fj.Owner,
+		Group:          fj.Group,
+		XAttrs:         fj.XAttrs,
+		ModifiedTime:   fj.ModifiedTime,
+		AccessedTime:   fj.AccessedTime,
+		ModifiedOffset: fj.ModifiedOffset,
+		AccessedOffset: fj.AccessedOffset,
+		DoNotCreate:    fj.DoNotCreate,
+		AllowEscape:    fj.AllowEscape,
+	}
+	for name, raw := range fj.Vars {
+		var v any
+		err = json.Unmarshal(raw, &v)
+		if err != nil {
+			err = dt.NewErr(ErrInvalidFixtureJSON, "path", path, "var", name, err)
+			goto end
+		}
+		if args.Vars == nil {
+			args.Vars = make(map[string]any, len(fj.Vars))
+		}
+		args.Vars[name] = v
+	}
+	if fj.Lock != nil {
+		args.Lock = &FileLockArgs{
+			Kind:            fj.Lock.Kind,
+			Start:           fj.Lock.Start,
+			Len:             fj.Lock.Len,
+			InHelperProcess: fj.Lock.InHelperProcess,
+		}
+	}
+	if fj.HoldOpen != nil {
+		args.HoldOpen = &HoldOpenArgs{
+			Writable:        fj.HoldOpen.Writable,
+			InHelperProcess: fj.HoldOpen.InHelperProcess,
+		}
+	}
+	if fj.Writer == nil {
+		goto end
+	}
+	if fj.Writer.DataFunc {
+		err = dt.NewErr(ErrFuncNotEncoded, "path", path, "field", "Writer.Data")
+		goto end
+	}
+	if fj.Writer.Interval != "" {
+		interval, err = time.ParseDuration(fj.Writer.Interval)
+		if err != nil {
+			err = dt.NewErr(ErrInvalidFixtureJSON, "path", path, "interval", fj.Writer.Interval, err)
+			goto end
+		}
+	}
+	args.Writer = &LiveWriterArgs{
+		Interval:      interval,
+		Count:         fj.Writer.Count,
+		TruncateEvery: fj.Writer.TruncateEvery,
+		RotateEvery:   fj.Writer.RotateEvery,
+		Rotation:      fj.Writer.Rotation,
+	}
+end:
+	return args, err
+}
@@ -0,0 +1,119 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// Fork returns a new, independently created root with the same content as
+// rf, for subtests that each mutate a large base tree. The fork's fixtures
+// are copies of rf's, one for one, so Lookup() and the other queries work
+// the same on both, and Cleanup() of either leaves the other in place.
+//
+// The fork is built as Create() builds rf, but each regular file's current
+// data is cloned from rf rather than written from its declaration: by
+// reflink on filesystems that support it, e.g. Btrfs or XFS, by sharing a
+// MemoryBackend's data until either file is first written, or otherwise by
+// copying. Hard links are never used on disk, where nothing can intercept
+// the first write to break them. Files of rf that cannot be read for lack of
+// permission get their declared content instead, and other failures to
+// clone a file fail t. Reset() of the fork restores declared content, even
+// where rf had been changed before it was forked.
+func (rf *RootFixture) Fork(t testing.TB) *RootFixture {
+	t.Helper()
+	rf.ensureCreated()
+	fork, cl := rf.clone()
+	for orig, c := range cl.copies {
+		ff, ok := orig.(*FileFixture)
+		if ok {
+			// Keeps a ContentFunc from being evaluated again for the fork
+			c.(*FileFixture).evaluated = ff.evaluated
+		}
+	}
+	fork.forkOf = rf
+	fork.Create(t)
+	fork.forkOf = nil
+	for _, c := range cl.copies {
+		ff, ok := c.(*FileFixture)
+		if ok {
+			// The data cloned may not be as declared, e.g. if rf was changed
+			// before forking, so make Reset() compare it with its hash
+			ff.stamp = fileStamp{}
+		}
+	}
+	return fork
+}
+
+// writeContent creates ff's file with perm, cloning the data of the same
+// file in the root being forked, if any, or else writing ff.Content.
+func (rf *RootFixture) writeContent(ff *FileFixture, perm fs.FileMode) error {
+	if rf.forkOf != nil {
+		rel, err := filepath.Rel(string(rf.tempDir), string(ff.Filepath))
+		if err == nil {
+			err = forkFile(rf.Backend(), filepath.Join(string(rf.forkOf.tempDir), rel), string(ff.Filepath), perm)
+		}
+		if !errors.Is(err, fs.ErrPermission) {
+			return err
+		}
+		// The original is unreadable, e.g. denied, so fall back to its
+		// declared content
+	}
+	return rf.Backend().WriteFile(string(ff.Filepath), []byte(ff.Content), perm)
+}
+
+// fileSharer is implemented by Backends that can create a file sharing the
+// data of another until either is written.
+type fileSharer interface {
+	shareFile(src, dst string, perm fs.FileMode) error
+}
+
+// forkFile creates dst in b with perm and the data of src, sharing the data
+// copy-on-write where b can, or else copying it.
+func forkFile(b Backend, src, dst string, perm fs.FileMode) (err error) {
+	var in, out BackendFile
+
+	sharer, ok := b.(fileSharer)
+	if ok {
+		return sharer.shareFile(src, dst, perm)
+	}
+	in, err = b.OpenFile(src, os.O_RDONLY, 0)
+	if err != nil {
+		goto end
+	}
+	defer func() { _ = in.Close() }()
+	out, err = b.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		goto end
+	}
+	if reflinkFiles(out, in) != nil {
+		_, err = io.Copy(out, in)
+	}
+	err = dt.CombineErrs([]error{err, out.Close()})
+end:
+	if err != nil {
+		err = dt.WithErr(err, "source", src, "path", dst)
+	}
+	return err
+}
+
+// reflinkFiles makes dst share the data blocks of src, if both are files on
+// disk whose filesystem supports reflinks.
+func reflinkFiles(dst, src BackendFile) error {
+	dstFile, ok := dst.(*os.File)
+	if !ok {
+		return errors.ErrUnsupported
+	}
+	srcFile, ok := src.(*os.File)
+	if !ok {
+		return errors.ErrUnsupported
+	}
+	return reflink(dstFile, srcFile)
+}
@@ -0,0 +1,149 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// HoldOpenArgs describes a handle kept open on a FileFixture from creation
+// until it is released or the fixture is cleaned up, e.g. to test code that
+// deletes or rotates files still in use.
+type HoldOpenArgs struct {
+	Writable        bool // Open for appending rather than read-only
+	InHelperProcess bool // Hold the handle in a spawned helper process rather than in this one
+}
+
+// openFlag returns the flag to open a held file with.
+func (args *HoldOpenArgs) openFlag() int {
+	if args.Writable {
+		return os.O_WRONLY | os.O_APPEND
+	}
+	return os.O_RDONLY
+}
+
+// openSpec returns the helper process spec for holding path open.
+func openSpec(path string, args *HoldOpenArgs) string {
+	mode := "r"
+	if args.Writable {
+		mode = "w"
+	}
+	return fmt.Sprintf("%s %s %s", openHelperOp, mode, path)
+}
+
+// openHeld opens the file described by the fields of an open helper spec,
+// returning a func that closes it.
+func openHeld(fields string) (release func() error, err error) {
+	var f *os.File
+
+	mode, path, _ := strings.Cut(fields, " ")
+	if path == "" || (mode != "r" && mode != "w") {
+		err = dt.NewErr(ErrInvalidHelperSpec, "op", openHelperOp, "fields", fields)
+		goto end
+	}
+	f, err = os.OpenFile(path, (&HoldOpenArgs{Writable: mode == "w"}).openFlag(), 0)
+	if err != nil {
+		goto end
+	}
+	release = f.Close
+end:
+	return release, err
+}
+
+// holdOpen opens the handle declared for ff, keeping it until released or
+// the fixture is cleaned up.
+func (ff *FileFixture) holdOpen() (err error) {
+	var release func() error
+
+	path := string(ff.Filepath)
+	if ff.HoldOpen.InHelperProcess {
+		release, err = spawnHelper(openSpec(path, ff.HoldOpen))
+	} else {
+		ff.handle, err = os.OpenFile(path, ff.HoldOpen.openFlag(), 0)
+		if ff.handle != nil {
+			release = ff.handle.Close
+		}
+	}
+	if err != nil {
+		err = dt.NewErr(ErrFailedToHoldOpen, "path", path, err)
+		goto end
+	}
+	ff.held = &heldResource{release: release}
+	rootOf(ff.Parent).keepAlive(ff.held)
+end:
+	return err
+}
+
+// Handle returns the handle held open on the file by this process, e.g. to
+// keep writing to it after Unlink() as a logger would.
+func (ff *FileFixture) Handle(t testing.TB) *os.File {
+	t.Helper()
+	if ff.handle == nil {
+		fatalf(t, "FileFixture '%s' is not held open by this process", ff.Name)
+	}
+	return ff.handle
+}
+
+// ReleaseHandle closes the handle held open on the file, e.g. to observe
+// the space of an unlinked file being freed.
+func (ff *FileFixture) ReleaseHandle(t testing.TB) {
+	t.Helper()
+	if ff.held == nil {
+		fatalf(t, "FileFixture '%s' is not held open", ff.Name)
+		return
+	}
+	err := ff.held.Close()
+	if err != nil {
+		t.Errorf("Failed to release handle on file fixture '%s'; %v", ff.Name, err)
+	}
+}
+
+// Unlink removes the file's directory entry while leaving any handle held on
+// it open, simulating a file deleted while in use.
+func (ff *FileFixture) Unlink(t testing.TB) {
+	t.Helper()
+	ff.ensureCreated(t)
+	err := rootOf(ff.Parent).Backend().Remove(string(ff.Filepath))
+	if err != nil {
+		t.Errorf("Failed to unlink file fixture '%s'; %v", ff.Name, err)
+	}
+}
+
+// Replace atomically renames a new file with content over the file, leaving
+// any handle held on it open on the original, simulating a file renamed over
+// while in use. The declaration is unchanged, so Reset() restores the
+// declared content.
+func (ff *FileFixture) Replace(t testing.TB, content string) {
+	var f BackendFile
+	var tmp string
+	var err error
+
+	t.Helper()
+	ff.ensureCreated(t)
+	b := rootOf(ff.Parent).Backend()
+	f, tmp, err = createTemp(b, filepath.Dir(string(ff.Filepath)), ".fsfix-replace-*", fileMode(ff.Permissions).Perm()|0200)
+	if err != nil {
+		goto end
+	}
+	_, err = f.Write([]byte(content))
+	err = dt.CombineErrs([]error{err, f.Close()})
+	if err == nil {
+		err = b.Chmod(tmp, fileMode(ff.Permissions))
+	}
+	if err == nil {
+		err = b.Rename(tmp, string(ff.Filepath))
+	}
+	if err != nil {
+		_ = b.Remove(tmp)
+	}
+end:
+	if err != nil {
+		t.Errorf("Failed to replace file fixture '%s'; %v", ff.Name, err)
+	}
+}
@@ -0,0 +1,150 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+//
+// Locks and open files held by a helper process are held by this executable
+// re-run with helperArg, which HelperMain() recognizes, so test binaries
+// using them must call HelperMain() from TestMain().
+package fsfix
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// helperArg is the first argument of a helper process, followed by its spec:
+// an op followed by the op's space-separated fields. It looks like a flag so
+// a test binary that does not call HelperMain() fails to parse it and exits
+// rather than running its tests again.
+const helperArg = "-fsfix.helper"
+
+const (
+	lockHelperOp = "lock" // Hold an advisory lock; see lockSpec()
+	openHelperOp = "open" // Hold a file open; see openSpec()
+)
+
+// helperReady is written by a helper process once it holds its resource.
+const helperReady = "ready"
+
+// HelperMain runs this process as a helper holding a lock or open file for a
+// fixture, and exits, if it was started as one; otherwise it returns. Test
+// binaries using InHelperProcess or range locks must call it first thing in
+// TestMain().
+func HelperMain() {
+	if len(os.Args) != 3 || os.Args[1] != helperArg {
+		return
+	}
+	os.Exit(runHelper(os.Args[2], os.Stdin, os.Stdout))
+}
+
+// heldResource is a lock or handle held until closed.
+type heldResource struct {
+	mu      sync.Mutex
+	release func() error
+}
+
+// Close releases the resource; it is safe to call more than once.
+func (r *heldResource) Close() (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.release != nil {
+		err = r.release()
+		r.release = nil
+	}
+	return err
+}
+
+// spawnHelper starts this executable as a helper process holding the
+// resource described by spec, returning once it is held. The helper releases
+// it and exits when its stdin is closed, including if this process dies, so
+// resources held by helpers have real cross-process semantics.
+func spawnHelper(spec string) (release func() error, err error) {
+	var exe, line string
+	var stdin io.WriteCloser
+	var stdout io.ReadCloser
+	var cmd *exec.Cmd
+
+	exe, err = os.Executable()
+	if err != nil {
+		goto end
+	}
+	cmd = exec.Command(exe, helperArg, spec)
+	cmd.Stderr = os.Stderr
+	stdin, err = cmd.StdinPipe()
+	if err != nil {
+		goto end
+	}
+	stdout, err = cmd.StdoutPipe()
+	if err != nil {
+		goto end
+	}
+	err = cmd.Start()
+	if err != nil {
+		goto end
+	}
+	line, err = bufio.NewReader(stdout).ReadString('\n')
+	line = strings.TrimSpace(line)
+	if line != helperReady {
+		_ = stdin.Close()
+		if line == "" {
+			// A test binary that never calls HelperMain() rejects helperArg
+			line = "none; does TestMain() call fsfix.HelperMain()?"
+		}
+		err = dt.CombineErrs([]error{
+			dt.NewErr(ErrHelperProcessFailed, "output", line),
+			err,
+			cmd.Wait(),
+		})
+		goto end
+	}
+	err = nil
+	release = func() error {
+		_ = stdin.Close()
+		return cmd.Wait()
+	}
+end:
+	return release, err
+}
+
+// runHelper acquires the resource described by spec, reports it is held on
+// out, then holds it until in is closed. Returns the process exit code.
+func runHelper(spec string, in io.Reader, out io.Writer) int {
+	var path string
+	var lock *FileLockArgs
+	var release func() error
+	var err error
+
+	op, fields, _ := strings.Cut(spec, " ")
+	switch op {
+	case lockHelperOp:
+		path, lock, err = parseLockSpec(fields)
+		if err == nil {
+			release, err = lockFile(path, lock)
+		}
+	case openHelperOp:
+		release, err = openHeld(fields)
+	default:
+		err = dt.NewErr(ErrInvalidHelperSpec, "spec", spec)
+	}
+	if err != nil {
+		goto end
+	}
+	_, err = fmt.Fprintln(out, helperReady)
+	if err != nil {
+		goto end
+	}
+	_, _ = io.Copy(io.Discard, in)
+	err = release()
+end:
+	if err != nil {
+		_, _ = fmt.Fprintf(out, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,262 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// RotationStyle is how a LiveWriter rotates the file it is growing.
+type RotationStyle int
+
+const (
+	RenameRotation       RotationStyle = iota // Rename the file to <name>.N and continue in a new file
+	CopyTruncateRotation                      // Copy the file to <name>.N and truncate it in place
+)
+
+func (s RotationStyle) String() string {
+	switch s {
+	case RenameRotation:
+		return "rename"
+	case CopyTruncateRotation:
+		return "copy-truncate"
+	}
+	return "unknown rotation"
+}
+
+// LiveWriterArgs describes a file that keeps growing after Create(), e.g.
+// for testing followers in the style of `tail -f`.
+type LiveWriterArgs struct {
+	Interval      time.Duration      // Time between writes; defaults to 10ms
+	Data          func(n int) []byte // Data appended by the nth write, from 1; defaults to "line n\n"
+	Count         int                // Stop after this many writes; 0 writes until stopped
+	TruncateEvery int                // Truncate the file in place after every this many writes; 0 never does
+	RotateEvery   int                // Rotate the file after every this many writes; 0 never does
+	Rotation      RotationStyle      // How to rotate when RotateEvery is set
+}
+
+// LiveWriter appends to a FileFixture on a goroutine, started once the
+// fixture tree has been created and stopped by Stop() or cleanup.
+type LiveWriter struct {
+	backend   Backend
+	path      dt.Filepath
+	perm      os.FileMode
+	args      LiveWriterArgs
+	stop      chan struct{}
+	done      chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+	mu        sync.Mutex
+	writes    int
+	rotations []dt.Filepath
+	err       error
+}
+
+// newLiveWriter returns a writer for path in b, applying defaults to args.
+func newLiveWriter(b Backend, path dt.Filepath, perm os.FileMode, args LiveWriterArgs) *LiveWriter {
+	if args.Interval <= 0 {
+		args.Interval = 10 * time.Millisecond
+	}
+	if args.Data == nil {
+		args.Data = func(n int) []byte {
+			return fmt.Appendf(nil, "line %d\n", n)
+		}
+	}
+	return &LiveWriter{
+		backend: b,
+		path:    path,
+		perm:    perm,
+		args:    args,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// start runs the writer on its own goroutine; it is safe to call more than once.
+func (lw *LiveWriter) start() {
+	lw.startOnce.Do(func() {
+		go lw.run()
+	})
+}
+
+// run appends on every tick until stopped, Count is reached or a write fails.
+func (lw *LiveWriter) run() {
+	var f BackendFile
+	var err error
+
+	defer close(lw.done)
+	ticker := time.NewTicker(lw.args.Interval)
+	defer ticker.Stop()
+
+	f, err = lw.open()
+	for n := 1; err == nil && (lw.args.Count == 0 || n <= lw.args.Count); n++ {
+		select {
+		case <-lw.stop:
+			goto end
+		case <-ticker.C:
+		}
+		_, err = f.Write(lw.args.Data(n))
+		if err != nil {
+			break
+		}
+		lw.mu.Lock()
+		lw.writes = n
+		lw.mu.Unlock()
+		switch {
+		case lw.args.RotateEvery > 0 && n%lw.args.RotateEvery == 0:
+			f, err = lw.rotate(f)
+		case lw.args.TruncateEvery > 0 && n%lw.args.TruncateEvery == 0:
+			err = lw.truncate()
+		}
+	}
+end:
+	if f != nil {
+		err = dt.CombineErrs([]error{err, f.Close()})
+	}
+	if err != nil {
+		err = dt.NewErr(ErrLiveWriterFailed, "path", lw.path, err)
+	}
+	lw.mu.Lock()
+	lw.err = err
+	lw.mu.Unlock()
+}
+
+// open opens the file for appending, creating it if rotation renamed it away.
+func (lw *LiveWriter) open() (BackendFile, error) {
+	return lw.backend.OpenFile(string(lw.path), os.O_WRONLY|os.O_APPEND|os.O_CREATE, lw.perm)
+}
+
+// truncate empties the file in place; writes continue at the new end as
+// the file is open for appending.
+func (lw *LiveWriter) truncate() error {
+	f, err := lw.backend.OpenFile(string(lw.path), os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// rotate moves the current contents to the next <name>.N, returning the file
+// to continue writing to.
+func (lw *LiveWriter) rotate(f BackendFile) (_ BackendFile, err error) {
+	var src, dst BackendFile
+
+	lw.mu.Lock()
+	rotated := dt.Filepath(fmt.Sprintf("%s.%d", lw.path, len(lw.rotations)+1))
+	lw.mu.Unlock()
+
+	switch lw.args.Rotation {
+	case RenameRotation:
+		// Closed first as Windows cannot rename open files
+		err = f.Close()
+		f = nil
+		if err != nil {
+			goto end
+		}
+		err = lw.backend.Rename(string(lw.path), string(rotated))
+		if err != nil {
+			goto end
+		}
+		f, err = lw.open()
+	case CopyTruncateRotation:
+		src, err = lw.backend.OpenFile(string(lw.path), os.O_RDONLY, 0)
+		if err != nil {
+			goto end
+		}
+		defer func() { _ = src.Close() }()
+		dst, err = lw.backend.OpenFile(string(rotated), os.O_WRONLY|os.O_CREATE|os.O_EXCL, lw.perm)
+		if err != nil {
+			goto end
+		}
+		_, err = io.Copy(dst, src)
+		err = dt.CombineErrs([]error{err, dst.Close()})
+		if err != nil {
+			goto end
+		}
+		err = lw.truncate()
+	default:
+		err = dt.NewErr(ErrUnknownRotationStyle, "rotation", int(lw.args.Rotation))
+	}
+	if err != nil {
+		goto end
+	}
+	lw.mu.Lock()
+	lw.rotations = append(lw.rotations, rotated)
+	lw.mu.Unlock()
+end:
+	if err != nil {
+		err = dt.WithErr(err, "rotated", rotated)
+	}
+	return f, err
+}
+
+// Stop stops the writer, waits for it to finish and returns the error that
+// ended it, if any. It is safe to call more than once.
+func (lw *LiveWriter) Stop() error {
+	lw.stopOnce.Do(func() {
+		close(lw.stop)
+	})
+	lw.start()
+	return lw.Wait()
+}
+
+// Wait waits for the writer to finish, e.g. once Count writes are done, and
+// returns the error that ended it, if any.
+func (lw *LiveWriter) Wait() error {
+	<-lw.done
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.err
+}
+
+// Close stops the writer; it lets cleanup stop writers along with other
+// resources held until then.
+func (lw *LiveWriter) Close() error {
+	return lw.Stop()
+}
+
+// Writes returns the number of writes completed so far.
+func (lw *LiveWriter) Writes() int {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.writes
+}
+
+// Rotations returns the paths the file has been rotated to so far, oldest first.
+func (lw *LiveWriter) Rotations() []dt.Filepath {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return append([]dt.Filepath(nil), lw.rotations...)
+}
+
+// LiveWriter returns the writer growing the file.
+func (ff *FileFixture) LiveWriter(t testing.TB) *LiveWriter {
+	t.Helper()
+	if ff.liveWriter == nil {
+		fatalf(t, "FileFixture '%s' has no live writer", ff.Name)
+	}
+	return ff.liveWriter
+}
+
+// recordWriter records lw to start once the fixture tree is complete and to
+// stop during cleanup.
+func (rf *RootFixture) recordWriter(lw *LiveWriter) {
+	rf.keepAlive(lw)
+	rf.pendingWriters = append(rf.pendingWriters, lw)
+}
+
+// startWriters starts every live writer once the fixture tree is complete,
+// so their writes cannot race with directory modes and times being applied.
+func (rf *RootFixture) startWriters() {
+	for _, lw := range rf.pendingWriters {
+		lw.start()
+	}
+	rf.pendingWriters = nil
+}
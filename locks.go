@@ -0,0 +1,153 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// LockKind is the type of advisory lock held on a FileFixture.
+type LockKind int
+
+const (
+	NoLock         LockKind = iota // The file is not locked
+	SharedFlock                    // A shared flock(2) lock on the whole file
+	ExclusiveFlock                 // An exclusive flock(2) lock on the whole file
+	ReadRangeLock                  // An fcntl(2) read lock on a byte range, always held by a helper process
+	WriteRangeLock                 // An fcntl(2) write lock on a byte range, always held by a helper process
+)
+
+func (k LockKind) String() string {
+	switch k {
+	case NoLock:
+		return "no lock"
+	case SharedFlock:
+		return "shared flock"
+	case ExclusiveFlock:
+		return "exclusive flock"
+	case ReadRangeLock:
+		return "read range lock"
+	case WriteRangeLock:
+		return "write range lock"
+	}
+	return "unknown lock"
+}
+
+// isRange reports whether the lock is an fcntl byte-range lock, which
+// belongs to the process and so never conflicts with its own holder.
+func (k LockKind) isRange() bool {
+	return k == ReadRangeLock || k == WriteRangeLock
+}
+
+// FileLockArgs describes an advisory lock held on a FileFixture from
+// creation until it is released or the fixture is cleaned up.
+type FileLockArgs struct {
+	Kind            LockKind
+	Start           int64 // First byte of a range lock
+	Len             int64 // Length of a range lock; 0 locks to the end of the file however it grows
+	InHelperProcess bool  // Hold a flock in a spawned helper process rather than in this one
+}
+
+// lockSpec returns the helper process spec for holding args on path.
+func lockSpec(path string, args *FileLockArgs) string {
+	return fmt.Sprintf("%s %d %d %d %s", lockHelperOp, args.Kind, args.Start, args.Len, path)
+}
+
+// parseLockSpec parses the fields of a lock helper spec following its op.
+func parseLockSpec(fields string) (path string, args *FileLockArgs, err error) {
+	var kind int
+
+	args = &FileLockArgs{}
+	parts := strings.SplitN(fields, " ", 4)
+	if len(parts) != 4 {
+		err = dt.NewErr(ErrInvalidHelperSpec, "op", lockHelperOp, "fields", fields)
+		goto end
+	}
+	kind, err = strconv.Atoi(parts[0])
+	if err != nil {
+		goto end
+	}
+	args.Kind = LockKind(kind)
+	args.Start, err = strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		goto end
+	}
+	args.Len, err = strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		goto end
+	}
+	path = parts[3]
+end:
+	return path, args, err
+}
+
+// acquireLock takes the lock declared for ff, keeping it until released or
+// the fixture is cleaned up.
+func (ff *FileFixture) acquireLock() (err error) {
+	var release func() error
+
+	args := ff.Lock
+	path := string(ff.Filepath)
+	switch {
+	case args.Kind == SharedFlock || args.Kind == ExclusiveFlock:
+		if args.InHelperProcess {
+			release, err = spawnHelper(lockSpec(path, args))
+			break
+		}
+		release, err = lockFile(path, args)
+	case args.Kind.isRange():
+		release, err = spawnHelper(lockSpec(path, args))
+	default:
+		err = dt.NewErr(ErrUnknownLockKind, "path", path, "kind", int(args.Kind))
+		goto end
+	}
+	if errors.Is(err, errors.ErrUnsupported) {
+		err = dt.NewErr(ErrLocksUnsupported, "path", path, "kind", args.Kind, err)
+		goto end
+	}
+	if err != nil {
+		err = dt.NewErr(ErrFailedToLockFile, "path", path, "kind", args.Kind, err)
+		goto end
+	}
+	ff.lock = &heldResource{release: release}
+	rootOf(ff.Parent).keepAlive(ff.lock)
+end:
+	return err
+}
+
+// ReleaseLock releases the lock held on the file, e.g. to let code under
+// test that backs off from the lock succeed on retry.
+func (ff *FileFixture) ReleaseLock(t testing.TB) {
+	t.Helper()
+	ff.ensureLocked(t)
+	err := ff.lock.Close()
+	if err != nil {
+		t.Errorf("Failed to release lock on file fixture '%s'; %v", ff.Name, err)
+	}
+}
+
+// ReleaseLockAfter releases the lock held on the file once d has elapsed,
+// without blocking, so code under test can be observed retrying until then.
+// Failures to release are logged.
+func (ff *FileFixture) ReleaseLockAfter(t testing.TB, d time.Duration) {
+	t.Helper()
+	ff.ensureLocked(t)
+	time.AfterFunc(d, func() {
+		dt.LogOnError(ff.lock.Close())
+	})
+}
+
+// ensureLocked fails t unless a lock was declared and acquired for the file.
+func (ff *FileFixture) ensureLocked(t testing.TB) {
+	t.Helper()
+	if ff.lock == nil {
+		fatalf(t, "FileFixture '%s' does not hold a lock", ff.Name)
+	}
+}
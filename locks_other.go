@@ -0,0 +1,15 @@
+//go:build !unix
+
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"errors"
+	"os"
+)
+
+// lockFile reports that advisory locks are only supported on Unix.
+func lockFile(path string, _ *FileLockArgs) (func() error, error) {
+	return nil, &os.PathError{Op: "lock", Path: path, Err: errors.ErrUnsupported}
+}
@@ -0,0 +1,51 @@
+//go:build unix
+
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile opens path and takes the lock described by args on it without
+// blocking, returning a func that releases the lock and closes the file.
+func lockFile(path string, args *FileLockArgs) (release func() error, err error) {
+	var f *os.File
+
+	flag := os.O_RDONLY
+	if args.Kind == WriteRangeLock {
+		flag = os.O_WRONLY
+	}
+	f, err = os.OpenFile(path, flag, 0)
+	if err != nil {
+		goto end
+	}
+	switch args.Kind {
+	case SharedFlock:
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_SH|syscall.LOCK_NB)
+	case ExclusiveFlock:
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	case ReadRangeLock, WriteRangeLock:
+		lk := syscall.Flock_t{
+			Type:   syscall.F_RDLCK,
+			Whence: 0,
+			Start:  args.Start,
+			Len:    args.Len,
+		}
+		if args.Kind == WriteRangeLock {
+			lk.Type = syscall.F_WRLCK
+		}
+		err = syscall.FcntlFlock(f.Fd(), syscall.F_SETLK, &lk)
+	}
+	if err != nil {
+		err = &os.PathError{Op: "lock", Path: path, Err: err}
+		_ = f.Close()
+		goto end
+	}
+	// Closing the file releases either kind of lock
+	release = f.Close
+end:
+	return release, err
+}
@@ -0,0 +1,215 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"io/fs"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// NewRootFixtureFromMapFS declares a RootFixture with the files and
+// directories of fsys, mapping each MapFile's Data, Mode and ModTime onto
+// the fixture's Content, Permissions and ModifiedTime. Zero permissions get
+// the usual defaults, and named pipes, sockets and devices map onto the
+// corresponding Kind. The fixture still needs to be created.
+func NewRootFixtureFromMapFS(t testing.TB, dirPrefix string, fsys fstest.MapFS, args *RootFixtureArgs) *RootFixture {
+	rf := NewRootFixtureWithArgs(dirPrefix, args)
+	for _, name := range slices.Sorted(maps.Keys(fsys)) {
+		mf := fsys[name]
+		if name == "." || mf == nil {
+			continue
+		}
+		perm := unixPermissions(mf.Mode)
+		switch mode := mf.Mode.Type(); {
+		case mode == fs.ModeDir:
+			rf.AddDirFixture(t, dt.PathSegments(name), &DirFixtureArgs{
+				Permissions:  perm,
+				ModifiedTime: mf.ModTime,
+			})
+		case mode&fs.ModeSymlink != 0:
+			fatalf(t, "MapFS entry '%s' is a symlink, which fixtures do not support", name)
+		default:
+			kind, ok := kindOfMode(mode)
+			if !ok {
+				fatalf(t, "MapFS entry '%s' has unsupported mode %s", name, mf.Mode)
+				continue
+			}
+			rf.AddFileFixture(t, dt.RelFilepath(name), &FileFixtureArgs{
+				Kind:         kind,
+				Content:      string(mf.Data),
+				Permissions:  perm,
+				ModifiedTime: mf.ModTime,
+			})
+		}
+	}
+	return rf
+}
+
+// unixPermissions converts mode to Unix permission bits as declared on
+// fixtures, e.g. 04755, the inverse of fileMode().
+func unixPermissions(mode fs.FileMode) int {
+	perm := int(mode.Perm())
+	if mode&fs.ModeSetuid != 0 {
+		perm |= 04000
+	}
+	if mode&fs.ModeSetgid != 0 {
+		perm |= 02000
+	}
+	if mode&fs.ModeSticky != 0 {
+		perm |= 01000
+	}
+	return perm
+}
+
+// kindOfMode returns the FileKind for the type bits of a non-directory mode.
+func kindOfMode(mode fs.FileMode) (kind FileKind, ok bool) {
+	ok = true
+	switch mode {
+	case 0:
+		kind = RegularFileKind
+	case fs.ModeNamedPipe:
+		kind = NamedPipeKind
+	case fs.ModeSocket:
+		kind = SocketKind
+	case fs.ModeDevice | fs.ModeCharDevice:
+		kind = CharDeviceKind
+	case fs.ModeDevice:
+		kind = BlockDeviceKind
+	default:
+		ok = false
+	}
+	return kind, ok
+}
+
+// modeOfKind returns the type bits of mode for kind, the inverse of kindOfMode().
+func modeOfKind(kind FileKind) fs.FileMode {
+	switch kind {
+	case NamedPipeKind:
+		return fs.ModeNamedPipe
+	case SocketKind:
+		return fs.ModeSocket
+	case CharDeviceKind:
+		return fs.ModeDevice | fs.ModeCharDevice
+	case BlockDeviceKind:
+		return fs.ModeDevice
+	}
+	return 0
+}
+
+// MapFS returns the declared fixture tree as an fstest.MapFS, whether or not
+// it has been created, so one declaration can serve both in-memory and
+// on-disk tests. Times declared as offsets are resolved against BaseTime,
+// or the current time if BaseTime is not set, and files marked DoNotCreate
+// are left out. A ContentFunc is called for content unless Create() already
+// has.
+func (rf *RootFixture) MapFS() fstest.MapFS {
+	mb := mapFSBuilder{
+		fsys:  make(fstest.MapFS),
+		clock: newClock(rf.BaseTime),
+	}
+	for _, ff := range rf.FileFixtures {
+		mb.addFile(".", ff)
+	}
+	for _, cf := range rf.ChildFixtures {
+		mb.addChild(".", cf)
+	}
+	return mb.fsys
+}
+
+// mapFSBuilder accumulates MapFS entries for a declared fixture tree.
+type mapFSBuilder struct {
+	fsys    fstest.MapFS
+	clock   clock
+	pending map[string]bool // If set, records files whose ContentFunc is not called
+}
+
+// mtime returns the modification time declared by ft, or zero. Offsets
+// were validated when their fixtures were added, so cannot fail here.
+func (mb *mapFSBuilder) mtime(ft fixtureTimes) time.Time {
+	_, mtime, _ := mb.clock.resolve(ft)
+	return mtime
+}
+
+// impliedDir returns the entry for a directory that is created without
+// being declared, e.g. an intermediate directory of a file's name.
+func (mb *mapFSBuilder) impliedDir(perm int) *fstest.MapFile {
+	return &fstest.MapFile{
+		Mode:    fs.ModeDir | fileMode(perm),
+		ModTime: mb.mtime(fixtureTimes{}),
+	}
+}
+
+// addDir adds a directory entry for name unless one was already declared.
+// Directories implied by a file's name are added with declared false.
+func (mb *mapFSBuilder) addDir(name string, mf *fstest.MapFile, declared bool) {
+	_, exists := mb.fsys[name]
+	if exists && !declared {
+		return
+	}
+	mb.fsys[name] = mf
+}
+
+// addFile adds ff, declared within the directory dir, and the directories
+// its name implies.
+func (mb *mapFSBuilder) addFile(dir string, ff *FileFixture) {
+	if ff.DoNotCreate {
+		return
+	}
+	name := path.Join(dir, filepath.ToSlash(string(ff.Name)))
+	for implied := path.Dir(name); implied != dir && implied != "."; implied = path.Dir(implied) {
+		mb.addDir(implied, mb.impliedDir(ff.DirPermissions), false)
+	}
+	content := ff.Content
+	switch {
+	case ff.ContentFunc == nil, ff.evaluated:
+	case mb.pending != nil:
+		mb.pending[name] = true
+	default:
+		content = ff.ContentFunc(ff)
+	}
+	mb.fsys[name] = &fstest.MapFile{
+		Data:    []byte(content),
+		Mode:    modeOfKind(ff.Kind) | fileMode(ff.Permissions),
+		ModTime: mb.mtime(ff.times()),
+	}
+}
+
+// addChild adds the directory or repository fixture cf, declared within
+// the directory dir, and everything beneath it.
+func (mb *mapFSBuilder) addChild(dir string, cf Fixture) {
+	var df *DirFixture
+
+	switch f := cf.(type) {
+	case *DirFixture:
+		df = f
+	case *RepoFixture:
+		df = f.DirFixture
+	default:
+		return
+	}
+	name := path.Join(dir, filepath.ToSlash(string(df.Name)))
+	for implied := path.Dir(name); implied != dir && implied != "."; implied = path.Dir(implied) {
+		mb.addDir(implied, mb.impliedDir(df.Permissions), false)
+	}
+	mb.addDir(name, &fstest.MapFile{
+		Mode:    fs.ModeDir | fileMode(df.Permissions),
+		ModTime: mb.mtime(df.times()),
+	}, true)
+	if _, ok := cf.(*RepoFixture); ok {
+		mb.addDir(path.Join(name, ".git"), mb.impliedDir(0755), false)
+	}
+	for _, ff := range df.FileFixtures {
+		mb.addFile(name, ff)
+	}
+	for _, child := range df.ChildFixtures {
+		mb.addChild(name, child)
+	}
+}
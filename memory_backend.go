@@ -0,0 +1,776 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// maxSymlinkHops is the most symlinks followed resolving one path, as on Linux.
+const maxSymlinkHops = 40
+
+// _ is a compile-time check to ensure MemoryBackend implements Backend.
+var _ Backend = (*MemoryBackend)(nil)
+
+// MemoryBackend is a writable in-memory Backend with modes, times and
+// symlinks, so fixtures can be created without any disk I/O. Permissions are
+// recorded but not enforced, as for root, and the tree starts out empty.
+type MemoryBackend struct {
+	mu      sync.RWMutex
+	root    *memNode
+	tempSeq int
+}
+
+// memNode is a file, directory or symlink in a MemoryBackend.
+type memNode struct {
+	mode     fs.FileMode
+	data     []byte
+	shared   bool   // Data is shared with another node, so copy it before writing
+	target   string // Symlink target
+	mtime    time.Time
+	atime    time.Time
+	ctime    time.Time // Status change time
+	ino      uint64
+	children map[string]*memNode
+}
+
+// memInodes numbers memNodes, so each has a distinct inode.
+var memInodes atomic.Uint64
+
+// NewMemoryBackend returns an empty MemoryBackend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		root: newMemNode(fs.ModeDir | 0755),
+	}
+}
+
+// newMemNode returns a node of mode timestamped now.
+func newMemNode(mode fs.FileMode) *memNode {
+	now := time.Now()
+	n := &memNode{mode: mode, mtime: now, atime: now, ctime: now, ino: memInodes.Add(1)}
+	if mode.IsDir() {
+		n.children = make(map[string]*memNode)
+	}
+	return n
+}
+
+// info returns a FileInfo for n named name.
+func (n *memNode) info(name string) fs.FileInfo {
+	return &memInfo{
+		name:  name,
+		size:  int64(len(n.data)),
+		mode:  n.mode,
+		mtime: n.mtime,
+		sys:   memSys{ctime: n.ctime, ino: n.ino},
+	}
+}
+
+// touch records a modification of n now.
+func (n *memNode) touch() {
+	n.mtime = time.Now()
+	n.ctime = n.mtime
+}
+
+// memInfo describes a memNode.
+type memInfo struct {
+	name  string
+	size  int64
+	mode  fs.FileMode
+	mtime time.Time
+	sys   memSys
+}
+
+// memSys is the Sys() of a memInfo, recording what a stat would beyond
+// fs.FileInfo.
+type memSys struct {
+	ctime time.Time
+	ino   uint64
+}
+
+func (fi *memInfo) Name() string       { return fi.name }
+func (fi *memInfo) Size() int64        { return fi.size }
+func (fi *memInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi *memInfo) ModTime() time.Time { return fi.mtime }
+func (fi *memInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *memInfo) Sys() any           { return fi.sys }
+
+// memPath splits name into the components of its absolute, cleaned path.
+func memPath(name string) (parts []string, err error) {
+	name, err = filepath.Abs(name)
+	if err != nil {
+		goto end
+	}
+	name = strings.TrimPrefix(name, filepath.VolumeName(name))
+	for part := range strings.SplitSeq(name, string(filepath.Separator)) {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+end:
+	return parts, err
+}
+
+// memJoin returns the absolute path of parts.
+func memJoin(parts []string) string {
+	return string(filepath.Separator) + filepath.Join(parts...)
+}
+
+// memLookup is the result of resolving a path in a MemoryBackend.
+type memLookup struct {
+	parts  []string // Components of the resolved path
+	node   *memNode // Entry at the path, or nil if it does not exist
+	parent *memNode // Directory containing the path; nil for the root
+}
+
+// base returns the final component of the resolved path.
+func (l memLookup) base() string {
+	if len(l.parts) == 0 {
+		return string(filepath.Separator)
+	}
+	return l.parts[len(l.parts)-1]
+}
+
+// lookup resolves name, following symlinks in all but the final component,
+// and the final component too if follow is set. A missing final component
+// is not an error, so callers can create it. Callers must hold mu.
+func (m *MemoryBackend) lookup(name string, follow bool) (memLookup, error) {
+	hops := 0
+	return m.resolve(name, follow, &hops)
+}
+
+// resolve is lookup, counting symlinks followed in hops.
+func (m *MemoryBackend) resolve(name string, follow bool, hops *int) (l memLookup, err error) {
+	var parts []string
+
+	parts, err = memPath(name)
+	if err != nil {
+		goto end
+	}
+	l.node = m.root
+	for i, part := range parts {
+		last := i == len(parts)-1
+		if !l.node.mode.IsDir() {
+			err = syscall.ENOTDIR
+			goto end
+		}
+		child := l.node.children[part]
+		if child == nil {
+			if !last {
+				err = fs.ErrNotExist
+				goto end
+			}
+			l.parts = append(slices.Clone(l.parts), part)
+			l.parent, l.node = l.node, nil
+			goto end
+		}
+		if child.mode&fs.ModeSymlink != 0 && (follow || !last) {
+			*hops++
+			if *hops > maxSymlinkHops {
+				err = syscall.ELOOP
+				goto end
+			}
+			target := child.target
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(memJoin(l.parts), target)
+			}
+			l, err = m.resolve(target, true, hops)
+			if err != nil {
+				goto end
+			}
+			if l.node == nil && !last {
+				err = fs.ErrNotExist
+				goto end
+			}
+			continue
+		}
+		l.parts = append(slices.Clone(l.parts), part)
+		l.parent, l.node = l.node, child
+	}
+end:
+	return l, err
+}
+
+// pathErr returns err as an *fs.PathError for op on name.
+func pathErr(op, name string, err error) error {
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// MkdirTemp creates a new directory in dir, creating dir itself if needed
+// as the tree starts out empty. An empty dir means os.TempDir().
+func (m *MemoryBackend) MkdirTemp(dir, pattern string) (name string, err error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	err = m.MkdirAll(dir, 0755)
+	if err != nil {
+		goto end
+	}
+	for {
+		m.mu.Lock()
+		m.tempSeq++
+		seq := strconv.Itoa(m.tempSeq)
+		m.mu.Unlock()
+		prefix, suffix, ok := strings.Cut(pattern, "*")
+		if !ok {
+			prefix, suffix = pattern, ""
+		}
+		name = filepath.Join(dir, prefix+seq+suffix)
+		err = m.mkdir(name, 0700)
+		if !os.IsExist(err) {
+			break
+		}
+	}
+end:
+	return name, err
+}
+
+// mkdir creates the directory name.
+func (m *MemoryBackend) mkdir(name string, perm fs.FileMode) (err error) {
+	var l memLookup
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, err = m.lookup(name, false)
+	switch {
+	case err != nil:
+	case l.node != nil:
+		err = fs.ErrExist
+	default:
+		l.parent.children[l.base()] = newMemNode(fs.ModeDir | perm&modeMask)
+		l.parent.touch()
+	}
+	if err != nil {
+		err = pathErr("mkdir", name, err)
+	}
+	return err
+}
+
+func (m *MemoryBackend) MkdirAll(path string, perm fs.FileMode) (err error) {
+	var info fs.FileInfo
+
+	info, err = m.Stat(path)
+	if err == nil {
+		if !info.IsDir() {
+			err = pathErr("mkdir", path, syscall.ENOTDIR)
+		}
+		goto end
+	}
+	if parent := filepath.Dir(path); parent != path {
+		err = m.MkdirAll(parent, perm)
+		if err != nil {
+			goto end
+		}
+	}
+	err = m.mkdir(path, perm)
+	if os.IsExist(err) {
+		// Created concurrently, or is a symlink to a directory
+		info, err = m.Stat(path)
+		if err == nil && !info.IsDir() {
+			err = pathErr("mkdir", path, syscall.ENOTDIR)
+		}
+	}
+end:
+	return err
+}
+
+func (m *MemoryBackend) OpenFile(name string, flag int, perm fs.FileMode) (_ BackendFile, err error) {
+	var l memLookup
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, err = m.lookup(name, true)
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	switch {
+	case err != nil:
+	case l.node == nil && flag&os.O_CREATE == 0:
+		err = fs.ErrNotExist
+	case l.node == nil:
+		l.node = newMemNode(perm & modeMask)
+		l.parent.children[l.base()] = l.node
+		l.parent.touch()
+	case flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		err = fs.ErrExist
+	case l.node.mode.IsDir() && writable:
+		err = syscall.EISDIR
+	case flag&os.O_TRUNC != 0 && writable:
+		l.node.data = nil
+		l.node.touch()
+	}
+	if err != nil {
+		return nil, pathErr("open", name, err)
+	}
+	return &memFile{m: m, node: l.node, name: name, flag: flag}, nil
+}
+
+func (m *MemoryBackend) WriteFile(name string, data []byte, perm fs.FileMode) (err error) {
+	var f BackendFile
+
+	f, err = m.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (m *MemoryBackend) ReadFile(name string) (_ []byte, err error) {
+	var f BackendFile
+
+	f, err = m.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return io.ReadAll(f)
+}
+
+func (m *MemoryBackend) ReadDir(name string) (_ []fs.DirEntry, err error) {
+	var f BackendFile
+
+	f, err = m.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return f.(*memFile).ReadDir(-1)
+}
+
+// stat returns info for name, following a final symlink if follow is set.
+func (m *MemoryBackend) stat(op, name string, follow bool) (_ fs.FileInfo, err error) {
+	var l memLookup
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	l, err = m.lookup(name, follow)
+	if err == nil && l.node == nil {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		return nil, pathErr(op, name, err)
+	}
+	return l.node.info(l.base()), nil
+}
+
+func (m *MemoryBackend) Stat(name string) (fs.FileInfo, error) {
+	return m.stat("stat", name, true)
+}
+
+func (m *MemoryBackend) Lstat(name string) (fs.FileInfo, error) {
+	return m.stat("lstat", name, false)
+}
+
+// update applies fn to the existing entry name, following a final symlink.
+func (m *MemoryBackend) update(op, name string, fn func(*memNode)) (err error) {
+	var l memLookup
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, err = m.lookup(name, true)
+	if err == nil && l.node == nil {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		return pathErr(op, name, err)
+	}
+	fn(l.node)
+	return nil
+}
+
+func (m *MemoryBackend) Chmod(name string, mode fs.FileMode) error {
+	return m.update("chmod", name, func(n *memNode) {
+		n.mode = n.mode&^modeMask | mode&modeMask
+		n.ctime = time.Now()
+	})
+}
+
+// Chtimes leaves zero times unchanged.
+func (m *MemoryBackend) Chtimes(name string, atime, mtime time.Time) error {
+	return m.update("chtimes", name, func(n *memNode) {
+		if !atime.IsZero() {
+			n.atime = atime
+		}
+		if !mtime.IsZero() {
+			n.mtime = mtime
+		}
+		n.ctime = time.Now()
+	})
+}
+
+func (m *MemoryBackend) Symlink(oldname, newname string) (err error) {
+	var l memLookup
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, err = m.lookup(newname, false)
+	if err == nil && l.node != nil {
+		err = fs.ErrExist
+	}
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+	n := newMemNode(fs.ModeSymlink | 0777)
+	n.target = oldname
+	l.parent.children[l.base()] = n
+	l.parent.touch()
+	return nil
+}
+
+// shareFile creates dst sharing the data of the regular file src, like a
+// hard link that is copied on the first write to either file.
+func (m *MemoryBackend) shareFile(src, dst string, perm fs.FileMode) (err error) {
+	var sl, dl memLookup
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sl, err = m.lookup(src, true)
+	switch {
+	case err != nil:
+	case sl.node == nil:
+		err = fs.ErrNotExist
+	case !sl.node.mode.IsRegular():
+		err = syscall.EINVAL
+	}
+	if err != nil {
+		return &os.LinkError{Op: "share", Old: src, New: dst, Err: err}
+	}
+	dl, err = m.lookup(dst, false)
+	if err == nil && dl.node != nil {
+		err = fs.ErrExist
+	}
+	if err != nil {
+		return &os.LinkError{Op: "share", Old: src, New: dst, Err: err}
+	}
+	n := newMemNode(perm & modeMask)
+	n.data, n.shared = sl.node.data, true
+	sl.node.shared = true
+	dl.parent.children[dl.base()] = n
+	dl.parent.touch()
+	return nil
+}
+
+func (m *MemoryBackend) Readlink(name string) (_ string, err error) {
+	var l memLookup
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	l, err = m.lookup(name, false)
+	switch {
+	case err != nil:
+	case l.node == nil:
+		err = fs.ErrNotExist
+	case l.node.mode&fs.ModeSymlink == 0:
+		err = fs.ErrInvalid
+	}
+	if err != nil {
+		return "", pathErr("readlink", name, err)
+	}
+	return l.node.target, nil
+}
+
+func (m *MemoryBackend) Rename(oldpath, newpath string) (err error) {
+	var from, to memLookup
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	from, err = m.lookup(oldpath, false)
+	if err == nil {
+		to, err = m.lookup(newpath, false)
+	}
+	switch {
+	case err != nil:
+	case from.node == nil:
+		err = fs.ErrNotExist
+	case from.parent == nil || to.parent == nil:
+		err = syscall.EBUSY
+	case from.node == to.node:
+		return nil
+	case from.node.mode.IsDir() && isPrefix(from.parts, to.parts):
+		err = fs.ErrInvalid
+	case to.node == nil:
+	case to.node.mode.IsDir() && !from.node.mode.IsDir():
+		err = syscall.EISDIR
+	case !to.node.mode.IsDir() && from.node.mode.IsDir():
+		err = syscall.ENOTDIR
+	case to.node.mode.IsDir() && len(to.node.children) > 0:
+		err = syscall.ENOTEMPTY
+	}
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	delete(from.parent.children, from.base())
+	to.parent.children[to.base()] = from.node
+	from.parent.touch()
+	to.parent.touch()
+	return nil
+}
+
+// isPrefix reports whether prefix is a leading run of parts.
+func isPrefix(prefix, parts []string) bool {
+	return len(prefix) <= len(parts) && slices.Equal(prefix, parts[:len(prefix)])
+}
+
+func (m *MemoryBackend) Remove(name string) (err error) {
+	var l memLookup
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, err = m.lookup(name, false)
+	switch {
+	case err != nil:
+	case l.node == nil:
+		err = fs.ErrNotExist
+	case l.parent == nil:
+		err = syscall.EBUSY
+	case l.node.mode.IsDir() && len(l.node.children) > 0:
+		err = syscall.ENOTEMPTY
+	}
+	if err != nil {
+		return pathErr("remove", name, err)
+	}
+	delete(l.parent.children, l.base())
+	l.parent.touch()
+	return nil
+}
+
+func (m *MemoryBackend) RemoveAll(path string) (err error) {
+	var l memLookup
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, err = m.lookup(path, false)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return pathErr("removeall", path, err)
+	case l.node == nil:
+		return nil
+	case l.parent == nil:
+		l.node.children = make(map[string]*memNode)
+		return nil
+	}
+	delete(l.parent.children, l.base())
+	l.parent.touch()
+	return nil
+}
+
+// DirFS returns an fs.FS rooted at dir.
+func (m *MemoryBackend) DirFS(dir string) fs.FS {
+	return &memFS{m: m, dir: dir}
+}
+
+// memFile is an open memNode.
+type memFile struct {
+	m       *MemoryBackend
+	node    *memNode
+	name    string
+	flag    int
+	offset  int64
+	entries []fs.DirEntry // Remaining directory entries once ReadDir starts
+	listed  bool
+	closed  bool
+}
+
+// check returns an error if the file is closed.
+func (f *memFile) check(op string) error {
+	if f.closed {
+		return pathErr(op, f.name, fs.ErrClosed)
+	}
+	return nil
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	err := f.check("stat")
+	if err != nil {
+		return nil, err
+	}
+	f.m.mu.RLock()
+	defer f.m.mu.RUnlock()
+	return f.node.info(filepath.Base(f.name)), nil
+}
+
+func (f *memFile) Read(p []byte) (n int, err error) {
+	err = f.check("read")
+	if err != nil {
+		return 0, err
+	}
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	switch {
+	case f.node.mode.IsDir():
+		return 0, pathErr("read", f.name, syscall.EISDIR)
+	case f.flag&os.O_WRONLY != 0:
+		return 0, pathErr("read", f.name, syscall.EBADF)
+	case f.offset >= int64(len(f.node.data)):
+		return 0, io.EOF
+	}
+	n = copy(p, f.node.data[f.offset:])
+	f.offset += int64(n)
+	f.node.atime = time.Now()
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (n int, err error) {
+	err = f.check("write")
+	if err != nil {
+		return 0, err
+	}
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return 0, pathErr("write", f.name, syscall.EBADF)
+	}
+	if f.node.shared {
+		f.node.data, f.node.shared = slices.Clone(f.node.data), false
+	}
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.node.data))
+	}
+	end := f.offset + int64(len(p))
+	if end > int64(len(f.node.data)) {
+		f.node.data = append(f.node.data, make([]byte, end-int64(len(f.node.data)))...)
+	}
+	copy(f.node.data[f.offset:], p)
+	f.offset = end
+	f.node.touch()
+	return len(p), nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (_ int64, err error) {
+	err = f.check("seek")
+	if err != nil {
+		return 0, err
+	}
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.node.data))
+	}
+	if offset < 0 {
+		return 0, pathErr("seek", f.name, fs.ErrInvalid)
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// ReadDir returns up to n entries of the directory, sorted by name.
+func (f *memFile) ReadDir(n int) (entries []fs.DirEntry, err error) {
+	err = f.check("readdir")
+	if err != nil {
+		return nil, err
+	}
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if !f.node.mode.IsDir() {
+		return nil, pathErr("readdir", f.name, syscall.ENOTDIR)
+	}
+	if !f.listed {
+		f.listed = true
+		for _, name := range slices.Sorted(func(yield func(string) bool) {
+			for name := range f.node.children {
+				if !yield(name) {
+					return
+				}
+			}
+		}) {
+			f.entries = append(f.entries, fs.FileInfoToDirEntry(f.node.children[name].info(name)))
+		}
+	}
+	if n <= 0 {
+		entries, f.entries = f.entries, nil
+		return entries, nil
+	}
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(f.entries))
+	entries, f.entries = f.entries[:n], f.entries[n:]
+	return entries, nil
+}
+
+func (f *memFile) Close() error {
+	err := f.check("close")
+	f.closed = true
+	return err
+}
+
+// memFS is an fs.FS view of a MemoryBackend directory.
+type memFS struct {
+	m   *MemoryBackend
+	dir string
+}
+
+// path returns the backend path for the fs.FS name, validating it.
+func (mfs *memFS) path(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", pathErr(op, name, fs.ErrInvalid)
+	}
+	return filepath.Join(mfs.dir, filepath.FromSlash(name)), nil
+}
+
+// fsErr rewrites the path of a backend *fs.PathError to the fs.FS name.
+func fsErr(name string, err error) error {
+	if pe, ok := err.(*fs.PathError); ok {
+		return pathErr(pe.Op, name, pe.Err)
+	}
+	return err
+}
+
+func (mfs *memFS) Open(name string) (fs.File, error) {
+	path, err := mfs.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := mfs.m.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, fsErr(name, err)
+	}
+	return f, nil
+}
+
+func (mfs *memFS) Stat(name string) (fs.FileInfo, error) {
+	path, err := mfs.path("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := mfs.m.Stat(path)
+	if err != nil {
+		return nil, fsErr(name, err)
+	}
+	return info, nil
+}
+
+func (mfs *memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	path, err := mfs.path("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := mfs.m.ReadDir(path)
+	return entries, fsErr(name, err)
+}
+
+func (mfs *memFS) ReadFile(name string) ([]byte, error) {
+	path, err := mfs.path("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := mfs.m.ReadFile(path)
+	return data, fsErr(name, err)
+}
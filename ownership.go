@@ -0,0 +1,136 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"os"
+	"os/user"
+	"runtime"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// CanChown reports whether this process may give entries the specified
+// owner and group, each given numerically or by name, with "" meaning
+// unchanged. It returns nil when it can, or an error giving the reason it
+// cannot, so tests can check up front before declaring ownership fixtures.
+func CanChown(owner, group string) (err error) {
+	var uid, gid int
+	var groups []int
+
+	if owner == "" && group == "" {
+		goto end
+	}
+	if runtime.GOOS == "windows" {
+		err = dt.NewErr(ErrOwnershipUnsupported, "goos", runtime.GOOS)
+		goto end
+	}
+	uid, gid, err = lookupOwnership(owner, group)
+	if err != nil {
+		goto end
+	}
+	if os.Geteuid() == 0 {
+		// Root may give any ownership
+		goto end
+	}
+	if uid != -1 && uid != os.Geteuid() {
+		err = dt.NewErr(ErrInsufficientPrivilege, "reason", "changing owner requires root", "owner", owner, "uid", uid)
+		goto end
+	}
+	if gid == -1 || gid == os.Getegid() {
+		goto end
+	}
+	groups, err = os.Getgroups()
+	if err != nil {
+		err = dt.NewErr(ErrInsufficientPrivilege, "reason", "cannot determine group membership", "group", group, err)
+		goto end
+	}
+	if !slices.Contains(groups, gid) {
+		err = dt.NewErr(ErrInsufficientPrivilege, "reason", "not a member of group", "group", group, "gid", gid)
+	}
+end:
+	return err
+}
+
+// lookupOwnership resolves owner and group, each numeric or a name, to IDs.
+// Empty values resolve to -1, meaning unchanged.
+func lookupOwnership(owner, group string) (uid, gid int, err error) {
+	uid, gid = -1, -1
+	if owner != "" {
+		uid, err = lookupID(owner, func(name string) (string, error) {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return "", err
+			}
+			return u.Uid, nil
+		})
+		if err != nil {
+			err = dt.NewErr(ErrUnknownOwner, "owner", owner, err)
+			goto end
+		}
+	}
+	if group != "" {
+		gid, err = lookupID(group, func(name string) (string, error) {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err
+			}
+			return g.Gid, nil
+		})
+		if err != nil {
+			err = dt.NewErr(ErrUnknownGroup, "group", group, err)
+		}
+	}
+end:
+	return uid, gid, err
+}
+
+// lookupID parses id as a number, else resolves it as a name via lookup.
+func lookupID(id string, lookup func(string) (string, error)) (n int, err error) {
+	n, err = strconv.Atoi(id)
+	if err == nil {
+		goto end
+	}
+	id, err = lookup(id)
+	if err != nil {
+		goto end
+	}
+	n, err = strconv.Atoi(id)
+end:
+	return n, err
+}
+
+// skipUnlessCanChown skips the test with the reason ownership cannot be given.
+func skipUnlessCanChown(t testing.TB, label string, owner, group string) {
+	if t == nil {
+		return
+	}
+	t.Helper()
+	err := CanChown(owner, group)
+	if err != nil {
+		t.Skipf("Skipping as ownership for %s cannot be applied; %v", label, err)
+	}
+}
+
+// lchown applies owner and group to path without following symlinks.
+func lchown(path dt.EntryPath, owner, group string) (err error) {
+	var uid, gid int
+
+	if owner == "" && group == "" {
+		goto end
+	}
+	uid, gid, err = lookupOwnership(owner, group)
+	if err != nil {
+		err = dt.WithErr(err, "path", path)
+		goto end
+	}
+	err = os.Lchown(string(path), uid, gid)
+	if err != nil {
+		err = dt.NewErr(ErrFailedToSetOwnership, "path", path, "uid", uid, "gid", gid, err)
+	}
+end:
+	return err
+}
@@ -0,0 +1,195 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// pkgPrefix is the prefix of fully-qualified function names in this package,
+// used to find the first caller outside of fsfix.
+var pkgPrefix = func() string {
+	name := runtime.FuncForPC(reflect.ValueOf(rootOf).Pointer()).Name()
+	return name[:strings.LastIndex(name, ".")+1]
+}()
+
+// pathEntry records which fixture claims a path relative to the fixture root.
+type pathEntry struct {
+	path    string
+	isDir   bool
+	implied bool    // Directory implied by a descendant rather than declared
+	fixture any     // *FileFixture, *DirFixture or *RepoFixture
+	parent  Fixture // Fixture the declaring fixture was added to
+	chain   string  // Description of the declaring fixture chain
+	caller  string  // file:line of the Add*Fixture call
+}
+
+// pathIndex maps cleaned root-relative paths to the fixtures that claim them.
+type pathIndex map[string]*pathEntry
+
+// callSite returns the file:line of the first caller outside this package.
+func callSite() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, pkgPrefix) {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return "unknown"
+}
+
+// rootOf returns the RootFixture at the top of f's hierarchy, or nil.
+func rootOf(f Fixture) *RootFixture {
+	for f != nil {
+		rf, ok := f.(*RootFixture)
+		if ok {
+			return rf
+		}
+		f = f.parentFixture()
+	}
+	return nil
+}
+
+// registerChild records the path claimed by fixture, being added to parent
+// as name, in the root's path index. Duplicates and file/directory conflicts
+// fail t naming both call sites, unless override is set in which case the
+// earlier claim is removed from the fixture tree.
+func registerChild(t testing.TB, parent Fixture, kind string, name string, fixture any, isDir bool, override bool) {
+	var rel string
+	var e *pathEntry
+
+	rf := rootOf(parent)
+	if rf == nil {
+		goto end
+	}
+	if rf.pathIndex == nil {
+		rf.pathIndex = make(pathIndex)
+	}
+	rel = filepath.Clean(filepath.Join(string(parent.RelativePath()), name))
+	e = &pathEntry{
+		path:    rel,
+		isDir:   isDir,
+		fixture: fixture,
+		parent:  parent,
+		chain:   fixtureChain(parent, kind, name),
+		caller:  callSite(),
+	}
+
+	// Every ancestor directory is implicitly claimed as a directory.
+	for dir := filepath.Dir(rel); dir != "." && dir != ".." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		prior, ok := rf.pathIndex[dir]
+		if ok && prior.isDir {
+			continue
+		}
+		if ok && !override {
+			reportConflict(t, "conflicts with", e, prior)
+			goto end
+		}
+		if ok {
+			rf.pathIndex.remove(prior)
+		}
+		rf.pathIndex[dir] = &pathEntry{path: dir, isDir: true, implied: true, fixture: fixture, chain: e.chain, caller: e.caller}
+	}
+
+	if prior, ok := rf.pathIndex[rel]; ok {
+		switch {
+		case prior.implied && isDir:
+			// Declaring a directory that was previously only implied is fine.
+		case override:
+			rf.pathIndex.remove(prior)
+		case prior.isDir != isDir:
+			reportConflict(t, "conflicts with", e, prior)
+			goto end
+		default:
+			reportConflict(t, "duplicates", e, prior)
+			goto end
+		}
+	}
+	rf.pathIndex[rel] = e
+
+	if rfx, ok := fixture.(*RepoFixture); ok {
+		git := filepath.Join(rel, ".git")
+		rf.pathIndex[git] = &pathEntry{path: git, isDir: true, implied: true, fixture: rfx, chain: e.chain, caller: e.caller}
+	}
+end:
+}
+
+// reportConflict fails t describing both claims on a path and where each was made.
+func reportConflict(t testing.TB, verb string, e, prior *pathEntry) {
+	if t != nil {
+		t.Helper()
+	}
+	fatalf(t, "Path '%s' declared by %s at %s %s %s '%s' declared by %s at %s; set Override to replace it intentionally",
+		e.path, e.chain, e.caller, verb, prior.kind(), prior.path, prior.chain, prior.caller)
+}
+
+// kind describes whether the entry claims a file or a directory.
+func (e *pathEntry) kind() string {
+	switch {
+	case e.implied:
+		return "implied directory"
+	case e.isDir:
+		return "directory"
+	}
+	return "file"
+}
+
+// remove removes e and every entry beneath it from the index, and removes
+// the declaring fixtures from their parents so they will not be created.
+func (idx pathIndex) remove(e *pathEntry) {
+	prefix := e.path + string(filepath.Separator)
+	for p, child := range idx {
+		if p != e.path && !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		delete(idx, p)
+		if !child.implied {
+			removeFromParent(child.parent, child.fixture)
+		}
+	}
+}
+
+// removeFromParent removes fixture from the file or child fixture list of parent.
+func removeFromParent(parent Fixture, fixture any) {
+	var files *[]*FileFixture
+	var children *[]Fixture
+
+	switch p := parent.(type) {
+	case *RootFixture:
+		files, children = &p.FileFixtures, &p.ChildFixtures
+	case *DirFixture:
+		files, children = &p.FileFixtures, &p.ChildFixtures
+	case *RepoFixture:
+		files, children = &p.FileFixtures, &p.ChildFixtures
+	default:
+		return
+	}
+	switch f := fixture.(type) {
+	case *FileFixture:
+		*files = removeItem(*files, f)
+	case Fixture:
+		*children = removeItem(*children, f)
+	}
+}
+
+// removeItem returns items without item, preserving order.
+func removeItem[T comparable](items []T, item T) []T {
+	out := items[:0]
+	for _, i := range items {
+		if i != item {
+			out = append(out, i)
+		}
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// modeMask covers the mode bits fixtures control exactly.
+const modeMask = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+
+// fileMode converts Unix permission bits as declared on fixtures, e.g. 04755,
+// to an os.FileMode including the setuid, setgid and sticky bits.
+func fileMode(perm int) os.FileMode {
+	mode := os.FileMode(perm) & os.ModePerm
+	if perm&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if perm&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if perm&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+// chmodExact sets the mode of path with an explicit chmod so the process umask
+// cannot filter it, then verifies the filesystem honoured the requested mode.
+func chmodExact(b Backend, path dt.EntryPath, mode os.FileMode) (err error) {
+	var info os.FileInfo
+
+	err = b.Chmod(string(path), mode)
+	if err != nil {
+		err = dt.NewErr(ErrFailedToSetMode, "path", path, "mode", mode, err)
+		goto end
+	}
+	if runtime.GOOS == "windows" && isOSBackend(b) {
+		// Windows only honours the owner write bit
+		goto end
+	}
+	info, err = b.Lstat(string(path))
+	if err != nil {
+		err = dt.NewErr(ErrFailedToSetMode, "path", path, "mode", mode, err)
+		goto end
+	}
+	if info.Mode()&modeMask != mode {
+		err = dt.NewErr(ErrModeNotHonored, "path", path, "requested", mode, "actual", info.Mode()&modeMask)
+	}
+end:
+	return err
+}
+
+// pendingDir is a directory whose mode and times are applied only once all
+// of its children exist, as creating children would change its mtime and a
+// restrictive mode could prevent creating them at all.
+type pendingDir struct {
+	path     dt.DirPath
+	mode     os.FileMode
+	keepMode bool // Leave the mode as created, e.g. for the root's temp directory
+	times    fixtureTimes
+	declared bool // Declared by a DirFixture rather than implied by a name
+}
+
+// recordDir records a directory to finalize. Directories declared by a
+// DirFixture take precedence over those implied by another fixture's name.
+// Directories outside the root, reached by names with AllowEscape, are
+// never recorded, so creating fixtures cannot change their modes or times.
+func (rf *RootFixture) recordDir(pd pendingDir) {
+	if !rf.contains(pd.path) {
+		return
+	}
+	if rf.pendingDirs == nil {
+		rf.pendingDirs = make(map[dt.DirPath]pendingDir)
+	}
+	prior, ok := rf.pendingDirs[pd.path]
+	if ok && prior.declared && !pd.declared {
+		return
+	}
+	rf.pendingDirs[pd.path] = pd
+}
+
+// contains reports whether path is the root's directory or beneath it.
+func (rf *RootFixture) contains(path dt.DirPath) bool {
+	rel, err := filepath.Rel(string(rf.tempDir), string(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// mkdirAll creates rel beneath base, then records rel and each intermediate
+// directory beneath base, up to any ".." part, to have mode applied exactly, whether or not it
+// already existed. Directories are created owner-writable so that children can
+// be added before the final mode is applied.
+func (rf *RootFixture) mkdirAll(base dt.DirPath, rel string, mode os.FileMode) (err error) {
+	dir := dt.DirPathJoin(base, rel)
+	err = rf.Backend().MkdirAll(string(dir), mode.Perm()|0700)
+	if err != nil {
+		err = dt.NewErr(ErrFailedToCreateDir, "path", dir, err)
+		goto end
+	}
+	for rel != "." && rel != "" && rel != string(filepath.Separator) && filepath.Base(rel) != ".." {
+		rf.recordDir(pendingDir{path: dt.DirPathJoin(base, rel), mode: mode})
+		rel = filepath.Dir(rel)
+	}
+end:
+	return err
+}
+
+// finalizeDirs applies the mode and times of every pending directory,
+// deepest first, and clears them.
+func (rf *RootFixture) finalizeDirs() error {
+	var errs []error
+
+	paths := make([]dt.DirPath, 0, len(rf.pendingDirs))
+	for path := range rf.pendingDirs {
+		paths = append(paths, path)
+	}
+	sortDeepestFirst(paths)
+	for _, path := range paths {
+		pd := rf.pendingDirs[path]
+		if !pd.keepMode {
+			errs = dt.AppendErr(errs, chmodExact(rf.Backend(), dt.EntryPath(path), pd.mode))
+		}
+		errs = dt.AppendErr(errs, rf.clock.apply(rf.Backend(), dt.EntryPath(path), pd.times))
+		rf.recordPristineDir(pd)
+	}
+	rf.pendingDirs = nil
+	return dt.CombineErrs(errs)
+}
+
+// sortDeepestFirst sorts paths so every directory follows those beneath it.
+func sortDeepestFirst(paths []dt.DirPath) {
+	slices.SortFunc(paths, func(a, b dt.DirPath) int {
+		depth := strings.Count(string(b), string(filepath.Separator)) - strings.Count(string(a), string(filepath.Separator))
+		if depth != 0 {
+			return depth
+		}
+		return strings.Compare(string(a), string(b))
+	})
+}
@@ -0,0 +1,23 @@
+//go:build linux
+
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"os"
+	"syscall"
+)
+
+// ficlone is the FICLONE ioctl request, _IOW(0x94, 9, int).
+const ficlone = 0x40049409
+
+// reflink makes dst share the data blocks of src copy-on-write, failing on
+// filesystems that do not support it.
+func reflink(dst, src *os.File) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dst.Fd(), ficlone, src.Fd())
+	if errno != 0 {
+		return &os.PathError{Op: "ficlone", Path: dst.Name(), Err: errno}
+	}
+	return nil
+}
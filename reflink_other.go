@@ -0,0 +1,15 @@
+//go:build !linux
+
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"errors"
+	"os"
+)
+
+// reflink is not supported on this platform, so forks copy file data.
+func reflink(*os.File, *os.File) error {
+	return errors.ErrUnsupported
+}
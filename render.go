@@ -0,0 +1,394 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing/fstest"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// RenderSource selects which state of a fixture tree Render() shows.
+type RenderSource int
+
+const (
+	DeclaredTree RenderSource = iota // The fixtures as declared, whether or not created
+	CreatedTree                      // What is actually in the fixture's directory
+	BothTrees                        // Declared and created side by side
+)
+
+// RenderOptions controls what Render() shows for each entry.
+type RenderOptions struct {
+	Source    RenderSource // Which state of the tree to show; defaults to DeclaredTree
+	HideModes bool         // Omit modes, e.g. -rw-r--r--
+	HideSizes bool         // Omit sizes of regular files
+	HideTimes bool         // Omit modification times
+}
+
+// renderColumns are the columns of detail for each RenderSource.
+var renderColumns = map[RenderSource][]int{
+	DeclaredTree: {declaredColumn},
+	CreatedTree:  {createdColumn},
+	BothTrees:    {declaredColumn, createdColumn},
+}
+
+const (
+	declaredColumn = iota
+	createdColumn
+)
+
+// String renders the tree like `tree`, with details of every entry: the
+// declared fixtures before Create(), or declared and created side by side
+// afterwards.
+func (rf *RootFixture) String() string {
+	return rf.Render(&RenderOptions{Source: defaultRenderSource(rf.created)})
+}
+
+// Render renders the tree like `tree`, showing what opts selects. A nil
+// opts renders the declared tree with every detail.
+func (rf *RootFixture) Render(opts *RenderOptions) string {
+	label := rf.fixtureLabel()
+	if rf.created {
+		label = string(rf.tempDir)
+	}
+	return renderTree(rf, label, rf.tempDir, rf.created, opts)
+}
+
+// String renders the directory's tree. See RootFixture.String().
+func (df *DirFixture) String() string {
+	return df.Render(&RenderOptions{Source: defaultRenderSource(df.created)})
+}
+
+// Render renders the directory's tree. See RootFixture.Render().
+func (df *DirFixture) Render(opts *RenderOptions) string {
+	return renderTree(df, renderLabel(df, df.dir, df.created), df.dir, df.created, opts)
+}
+
+// String renders the repository's tree. See RootFixture.String().
+func (rf *RepoFixture) String() string {
+	return rf.Render(&RenderOptions{Source: defaultRenderSource(rf.created)})
+}
+
+// Render renders the repository's tree. See RootFixture.Render().
+func (rf *RepoFixture) Render(opts *RenderOptions) string {
+	return renderTree(rf, renderLabel(rf, rf.dir, rf.created)+" [repo]", rf.dir, rf.created, opts)
+}
+
+// defaultRenderSource returns what String() shows for a fixture.
+func defaultRenderSource(created bool) RenderSource {
+	if created {
+		return BothTrees
+	}
+	return DeclaredTree
+}
+
+// renderLabel returns the first line of a rendered directory fixture.
+func renderLabel(f Fixture, dir dt.DirPath, created bool) string {
+	if created {
+		return string(dir)
+	}
+	return filepath.ToSlash(string(f.RelativePath()))
+}
+
+// logTreeOnFailure logs the declared and created trees side by side if the
+// test has failed, while they can still be compared.
+func (rf *RootFixture) logTreeOnFailure() {
+	if rf.t == nil || !rf.created || !rf.t.Failed() {
+		return
+	}
+	rf.t.Helper()
+	rf.t.Logf("Fixture tree for %s:\n%s", rf.fixtureLabel(), rf.Render(&RenderOptions{Source: BothTrees}))
+}
+
+// renderInfo holds the details shown for one entry in one column.
+type renderInfo struct {
+	mode        fs.FileMode
+	size        int64 // Size of a regular file; -1 for anything else
+	pendingFunc bool  // Size is unknown as the file's ContentFunc has not been called
+	mtime       time.Time
+	target      string // Target of a symlink
+	note        string // Shown instead of the other details, e.g. "DoNotCreate"
+}
+
+// renderNode is an entry in the tree being rendered, with its details for
+// the declared and created columns.
+type renderNode struct {
+	name     string
+	repo     bool
+	infos    [2]*renderInfo
+	children map[string]*renderNode
+}
+
+// insert returns the node at the slash-separated rel beneath n, adding it
+// and any intermediate nodes as needed.
+func (n *renderNode) insert(rel string) *renderNode {
+	if rel == "." {
+		return n
+	}
+	for _, name := range strings.Split(rel, "/") {
+		if n.children == nil {
+			n.children = make(map[string]*renderNode)
+		}
+		child, ok := n.children[name]
+		if !ok {
+			child = &renderNode{name: name}
+			n.children[name] = child
+		}
+		n = child
+	}
+	return n
+}
+
+// isDir reports whether n should be rendered as a directory.
+func (n *renderNode) isDir() bool {
+	for _, info := range n.infos {
+		if info != nil && info.note == "" {
+			return info.mode.IsDir()
+		}
+	}
+	return len(n.children) > 0
+}
+
+// typeMarker returns the `ls -F` style marker for n's type.
+func (n *renderNode) typeMarker() string {
+	var mode fs.FileMode
+	for _, info := range n.infos {
+		if info != nil && info.note == "" {
+			mode = info.mode
+			break
+		}
+	}
+	switch {
+	case n.isDir():
+		return "/"
+	case mode&fs.ModeSymlink != 0:
+		return "@"
+	case mode&fs.ModeNamedPipe != 0:
+		return "|"
+	case mode&fs.ModeSocket != 0:
+		return "="
+	}
+	return ""
+}
+
+// renderTree renders the tree beneath f using label as its first line.
+func renderTree(f Fixture, label string, dir dt.DirPath, created bool, opts *RenderOptions) string {
+	if opts == nil {
+		opts = &RenderOptions{}
+	}
+	columns, ok := renderColumns[opts.Source]
+	if !ok {
+		columns = renderColumns[DeclaredTree]
+	}
+	top := &renderNode{}
+	for _, column := range columns {
+		switch column {
+		case declaredColumn:
+			addDeclared(top, f)
+		case createdColumn:
+			addCreated(top, f, dir, created)
+		}
+	}
+	for _, tn := range treeNodes(f) {
+		if _, ok := tn.node.(*RepoFixture); ok {
+			top.insert(tn.path).repo = true
+		}
+	}
+
+	var rows [][]string
+	topRow := []string{label}
+	for _, column := range columns {
+		topRow = append(topRow, noteOf(top.infos[column]))
+	}
+	if len(columns) > 1 {
+		rows = append(rows, []string{"", "DECLARED", "CREATED"})
+	}
+	rows = append(rows, topRow)
+	rows = appendRows(rows, top, "", columns, opts)
+	return formatRows(rows)
+}
+
+// noteOf returns the note of info, if any.
+func noteOf(info *renderInfo) string {
+	if info == nil {
+		return ""
+	}
+	return info.note
+}
+
+// addDeclared adds the fixtures declared beneath f to the declared column,
+// without calling any ContentFunc Create() has not, as rendering must not
+// run user code.
+func addDeclared(top *renderNode, f Fixture) {
+	rf := rootOf(f)
+	if rf == nil {
+		return
+	}
+	mb := mapFSBuilder{
+		fsys:    make(fstest.MapFS),
+		clock:   newClock(rf.BaseTime),
+		pending: make(map[string]bool),
+	}
+	if rf.created {
+		mb.clock = rf.clock
+	}
+	files, children := fixturesOf(f)
+	for _, ff := range files {
+		mb.addFile(".", ff)
+	}
+	for _, cf := range children {
+		mb.addChild(".", cf)
+	}
+	if _, ok := f.(*RepoFixture); ok {
+		mb.addDir(".git", mb.impliedDir(0755), false)
+	}
+	for name, mf := range mb.fsys {
+		info := &renderInfo{mode: mf.Mode, size: -1, mtime: mf.ModTime, pendingFunc: mb.pending[name]}
+		if mf.Mode.IsRegular() && !info.pendingFunc {
+			info.size = int64(len(mf.Data))
+		}
+		top.insert(name).infos[declaredColumn] = info
+	}
+	for _, tn := range treeNodes(f) {
+		n, ok := tn.node.(*FileFixture)
+		if ok && n.DoNotCreate {
+			top.insert(tn.path).infos[declaredColumn] = &renderInfo{size: -1, note: "DoNotCreate"}
+		}
+	}
+}
+
+// addCreated adds what is actually beneath dir to the created column.
+func addCreated(top *renderNode, f Fixture, dir dt.DirPath, created bool) {
+	if !created {
+		top.infos[createdColumn] = &renderInfo{size: -1, note: "not yet created"}
+		return
+	}
+	addCreatedDir(rootOf(f).Backend(), string(dir), top)
+}
+
+// addCreatedDir adds the entries of dir in b beneath node, recursively.
+func addCreatedDir(b Backend, dir string, node *renderNode) {
+	entries, err := b.ReadDir(dir)
+	if err != nil {
+		node.infos[createdColumn] = &renderInfo{size: -1, note: fmt.Sprintf("unreadable: %v", err)}
+		return
+	}
+	for _, entry := range entries {
+		entryPath := filepath.Join(dir, entry.Name())
+		child := node.insert(entry.Name())
+		fi, err := b.Lstat(entryPath)
+		if err != nil {
+			child.infos[createdColumn] = &renderInfo{size: -1, note: fmt.Sprintf("unreadable: %v", err)}
+			continue
+		}
+		info := &renderInfo{mode: fi.Mode(), size: -1, mtime: fi.ModTime()}
+		child.infos[createdColumn] = info
+		switch {
+		case fi.Mode().IsRegular():
+			info.size = fi.Size()
+		case fi.Mode()&fs.ModeSymlink != 0:
+			info.target, _ = b.Readlink(entryPath)
+		case fi.IsDir():
+			addCreatedDir(b, entryPath, child)
+		}
+	}
+}
+
+// appendRows appends a row for each child of node, sorted by name, then
+// their children, drawing the branches of the tree.
+func appendRows(rows [][]string, node *renderNode, indent string, columns []int, opts *RenderOptions) [][]string {
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for i, name := range names {
+		child := node.children[name]
+		branch, nextIndent := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, nextIndent = "└── ", "    "
+		}
+		label := indent + branch + name + child.typeMarker()
+		if child.repo {
+			label += " [repo]"
+		}
+		row := []string{label}
+		for _, column := range columns {
+			row = append(row, renderDetails(child.infos[column], len(columns) > 1, opts))
+		}
+		rows = append(rows, row)
+		rows = appendRows(rows, child, indent+nextIndent, columns, opts)
+	}
+	return rows
+}
+
+// renderDetails returns the details of info that opts selects. Entries
+// absent from one side of a side-by-side rendering are shown as "-".
+func renderDetails(info *renderInfo, sideBySide bool, opts *RenderOptions) string {
+	var parts []string
+
+	switch {
+	case info == nil && sideBySide:
+		return "-"
+	case info == nil:
+		return ""
+	case info.note != "":
+		return info.note
+	}
+	if !opts.HideModes {
+		parts = append(parts, info.mode.String())
+	}
+	switch {
+	case opts.HideSizes:
+	case info.pendingFunc:
+		parts = append(parts, "ContentFunc")
+	case info.size >= 0:
+		parts = append(parts, fmt.Sprintf("%dB", info.size))
+	}
+	if !opts.HideTimes && !info.mtime.IsZero() {
+		parts = append(parts, info.mtime.UTC().Format(time.RFC3339))
+	}
+	if info.target != "" {
+		parts = append(parts, "-> "+info.target)
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatRows aligns rows into columns separated by two spaces. Empty
+// trailing cells are dropped, and the last cell of a row does not widen its
+// column, so e.g. a long first line does not push the details aside.
+func formatRows(rows [][]string) string {
+	var widths []int
+	for i, row := range rows {
+		for len(row) > 0 && row[len(row)-1] == "" {
+			row = row[:len(row)-1]
+		}
+		rows[i] = row
+		for j, cell := range row[:max(len(row)-1, 0)] {
+			if j >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[j] = max(widths[j], utf8.RuneCountInString(cell))
+		}
+	}
+	sb := strings.Builder{}
+	for _, row := range rows {
+		line := strings.Builder{}
+		for i, cell := range row {
+			line.WriteString(cell)
+			if i < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2))
+			}
+		}
+		sb.WriteString(strings.TrimRight(line.String(), " "))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -3,6 +3,8 @@
 package fsfix
 
 import (
+	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -17,18 +19,23 @@ type RepoFixture struct {
 	*DirFixture
 	created bool
 	Parent  Fixture
-	t       *testing.T
+	t       testing.TB
 }
 
 // RepoFixtureArgs contains arguments for creating a RepoFixture.
 type RepoFixtureArgs struct {
-	Files        []*FileFixture // Files to create within this project
-	Permissions  int            // Directory permissions
-	ModifiedTime time.Time      // Modification time for the directory
+	Files          []*FileFixture // Files to create within this project
+	Permissions    int            // Directory permissions
+	ModifiedTime   time.Time      // Modification time for the directory
+	AccessedTime   time.Time      // Access time for the directory; defaults to ModifiedTime
+	ModifiedOffset string         // Modification time offset from the RootFixture's BaseTime, e.g. "-72h"
+	AccessedOffset string         // Access time offset from the RootFixture's BaseTime, e.g. "-72h"
+	AllowEscape    bool           // Permit the name to resolve outside the fixture root
+	Override       bool           // Intentionally replace an earlier fixture declaring the same path
 }
 
 // newRepoFixture creates a new repository fixture with the specified name and arguments.
-func newRepoFixture(t *testing.T, name dt.PathSegments, parent Fixture, args *RepoFixtureArgs) (rf *RepoFixture) {
+func newRepoFixture(t testing.TB, name dt.PathSegments, parent Fixture, args *RepoFixtureArgs) (rf *RepoFixture) {
 	if args == nil {
 		args = &RepoFixtureArgs{}
 	}
@@ -40,8 +47,13 @@ func newRepoFixture(t *testing.T, name dt.PathSegments, parent Fixture, args *Re
 		Parent: parent, // TODO: Repo being parent of Dir might cause issues when compsing directories; need to test for that
 	}
 	rf.DirFixture = newDirFixture(t, name, rf, &DirFixtureArgs{
-		ModifiedTime: args.ModifiedTime,
-		Permissions:  args.Permissions,
+		ModifiedTime:   args.ModifiedTime,
+		AccessedTime:   args.AccessedTime,
+		ModifiedOffset: args.ModifiedOffset,
+		AccessedOffset: args.AccessedOffset,
+		Permissions:    args.Permissions,
+		AllowEscape:    args.AllowEscape,
+		Files:          args.Files,
 	})
 	return rf
 }
@@ -50,6 +62,14 @@ func (rf *RepoFixture) RelativePath() dt.DirPath {
 	return dt.DirPathJoin(rf.Parent.RelativePath(), rf.Name)
 }
 
+func (rf *RepoFixture) parentFixture() Fixture {
+	return rf.Parent
+}
+
+func (rf *RepoFixture) fixtureLabel() string {
+	return fmt.Sprintf("RepoFixture '%s'", rf.Name)
+}
+
 func (rf *RepoFixture) GitPath() dt.DirPath {
 	return dt.DirPathJoin(rf.Dir(), ".git")
 }
@@ -60,25 +80,22 @@ func (rf *RepoFixture) RelativeGitPath() dt.DirPath {
 
 // ensureCreated forces a failure if called before Create() is called.
 func (rf *RepoFixture) ensureCreated() {
-	rf.t.Helper()
 	if !rf.created {
-		rf.t.Fatalf("RepoFixture '%s' has not yet been created", rf.Name)
+		fatalf(rf.t, "RepoFixture '%s' has not yet been created", rf.Name)
 	}
 }
 
 // CreateWithParent creates the repository structure and files for this fixture with the specified parent.
-func (rf *RepoFixture) createWithParent(t *testing.T, parent Fixture) {
-	t.Helper()
+func (rf *RepoFixture) createWithParent(ctx context.Context, parent Fixture) error {
+	var errs []error
+
 	rf.created = true
-	rf.DirFixture.createWithParent(t, parent)
+	errs = dt.AppendErr(errs, rf.DirFixture.createWithParent(ctx, parent))
 
 	// Create .git directory to simulate making it a valid repo
 	// TODO: Maybe we could shell out to `git init` here if anyone ever needs that
-	gitDir := dt.DirPathJoin(rf.dir, ".git")
-	err := dt.MkdirAll(gitDir, 0755)
-	if err != nil {
-		t.Errorf("Failed to create .git directory within %s; %v", rf.dir, err)
-	}
+	errs = dt.AppendErr(errs, rootOf(rf).mkdirAll(rf.dir, ".git", 0755))
+	return dt.CombineErrs(errs)
 }
 
 // MakeDir creates a path relative to this repository fixture.
@@ -88,22 +105,30 @@ func (rf *RepoFixture) MakeDir(fp string) dt.DirPath {
 }
 
 // AddRepoFixture adds a sub-repository fixture to this repository fixture.
-func (rf *RepoFixture) AddRepoFixture(t *testing.T, name dt.PathSegments, args *RepoFixtureArgs) *RepoFixture {
+func (rf *RepoFixture) AddRepoFixture(t testing.TB, name dt.PathSegments, args *RepoFixtureArgs) *RepoFixture {
 	child := newRepoFixture(t, name, rf, args)
+	validateChild(t, rf, "RepoFixture", string(name), child.AllowEscape)
+	registerChild(t, rf, "RepoFixture", string(name), child, true, args != nil && args.Override)
+	adoptFiles(t, child, child.FileFixtures)
 	rf.ChildFixtures = append(rf.ChildFixtures, child)
 	return child
 }
 
 // AddDirFixture adds a directory fixture to this repository fixture.
-func (rf *RepoFixture) AddDirFixture(t *testing.T, name dt.PathSegments, args *DirFixtureArgs) *DirFixture {
+func (rf *RepoFixture) AddDirFixture(t testing.TB, name dt.PathSegments, args *DirFixtureArgs) *DirFixture {
 	child := newDirFixture(t, name, rf, args)
+	validateChild(t, rf, "DirFixture", string(name), child.AllowEscape)
+	registerChild(t, rf, "DirFixture", string(name), child, true, args != nil && args.Override)
+	adoptFiles(t, child, child.FileFixtures)
 	rf.ChildFixtures = append(rf.ChildFixtures, child)
 	return child
 }
 
 // AddFileFixture adds a file fixture to a project fixture
-func (rf *RepoFixture) AddFileFixture(t *testing.T, name dt.RelFilepath, args *FileFixtureArgs) *FileFixture {
+func (rf *RepoFixture) AddFileFixture(t testing.TB, name dt.RelFilepath, args *FileFixtureArgs) *FileFixture {
 	child := newFileFixture(t, name, rf, args)
+	validateChild(t, rf, "FileFixture", string(name), child.AllowEscape)
+	registerChild(t, rf, "FileFixture", string(name), child, false, args != nil && args.Override)
 	rf.FileFixtures = append(rf.FileFixtures, child)
 	return child
 }
@@ -112,18 +137,18 @@ func (rf *RepoFixture) AddFileFixture(t *testing.T, name dt.RelFilepath, args *F
 // FileFixtureArgs when one of args is passed just as a string(string) and it
 // gets its content from ContentFunc, or a FileFixtureArgs is passed which much
 // include Name.
-func (rf *RepoFixture) AddFileFixtures(t *testing.T, defaults *FileFixtureArgs, args ...any) {
+func (rf *RepoFixture) AddFileFixtures(t testing.TB, defaults *FileFixtureArgs, args ...any) {
 	for _, f := range args {
 		switch ffa := f.(type) {
 		case dt.RelFilepath:
 			rf.AddFileFixture(t, ffa, defaults)
 		case *FileFixtureArgs:
 			if ffa.Name == "" {
-				t.Fatalf("Name not set for file fixure being added to project fixture '%s'", rf.Name)
+				fatalf(t, "Name not set for file fixure being added to project fixture '%s'", rf.Name)
 			}
 			rf.AddFileFixture(t, ffa.Name, ffa)
 		default:
-			t.Fatalf("Invalid type '%T' passed for file fixure being added to project fixture: '%v'", f, f)
+			fatalf(t, "Invalid type '%T' passed for file fixure being added to project fixture: '%v'", f, f)
 		}
 	}
 }
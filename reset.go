@@ -0,0 +1,274 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// Reset restores a created fixture tree in place to its state just after
+// Create(), so subtests can share one fixture without seeing each other's
+// changes. Undeclared entries are removed, missing or modified ones are
+// recreated, and modes and times are applied again. Files are compared with
+// a hash of their content recorded by Create(), but where the status change
+// time is available, i.e. on Linux and with a MemoryBackend, files whose
+// size, times and inode are as created are not even read, so resetting a
+// large tree is much faster than Cleanup() and Create().
+//
+// Locks, held handles and live writers are left as they are; stop any live
+// writer before calling Reset() as the file it grows is reset too.
+func (rf *RootFixture) Reset(t testing.TB) {
+	t.Helper()
+	rf.ensureCreated()
+	err := rf.reset()
+	if err != nil {
+		t.Fatalf("Failed to reset root fixture '%s'; %v", rf.DirPrefix, err)
+	}
+}
+
+// recordPristineDir records the state of a finalized directory for Reset().
+func (rf *RootFixture) recordPristineDir(pd pendingDir) {
+	if rf.pristineDirs == nil {
+		rf.pristineDirs = make(map[dt.DirPath]pendingDir)
+	}
+	rf.pristineDirs[pd.path] = pd
+}
+
+// reset restores every created directory and file to its recorded state.
+func (rf *RootFixture) reset() error {
+	var errs []error
+
+	b := rf.Backend()
+	files := rf.createdFiles()
+	dirs := slices.Collect(maps.Keys(rf.pristineDirs))
+	sortDeepestFirst(dirs)
+
+	// Shallowest first, so restrictive modes cannot stop entries beneath
+	// from being listed or replaced
+	for _, dir := range slices.Backward(dirs) {
+		info, err := b.Lstat(string(dir))
+		if err == nil && info.IsDir() && info.Mode().Perm()&0700 != 0700 {
+			errs = dt.AppendErr(errs, chmodExact(b, dt.EntryPath(dir), info.Mode()&modeMask|0700))
+		}
+	}
+	errs = dt.AppendErr(errs, rf.pruneUndeclared(b, string(rf.tempDir), files))
+	for _, dir := range slices.Backward(dirs) {
+		err := b.MkdirAll(string(dir), 0700)
+		if err != nil {
+			errs = append(errs, dt.NewErr(ErrFailedToResetEntry, "path", dir, err))
+		}
+	}
+	for _, ff := range files {
+		errs = dt.AppendErr(errs, ff.reset(b))
+	}
+	for _, dir := range dirs {
+		errs = dt.AppendErr(errs, rf.resetDir(b, rf.pristineDirs[dir]))
+	}
+	return dt.CombineErrs(errs)
+}
+
+// createdFiles returns the files created in the tree, by path.
+func (rf *RootFixture) createdFiles() map[string]*FileFixture {
+	files := make(map[string]*FileFixture)
+	for _, tn := range treeNodes(rf) {
+		ff, ok := tn.node.(*FileFixture)
+		if ok && ff.created && !ff.DoNotCreate && ff.Filepath != "" {
+			files[string(ff.Filepath)] = ff
+		}
+	}
+	return files
+}
+
+// pruneUndeclared removes everything beneath dir that was not created as a
+// directory or file of the same type, recursively.
+func (rf *RootFixture) pruneUndeclared(b Backend, dir string, files map[string]*FileFixture) error {
+	var errs []error
+
+	entries, err := b.ReadDir(dir)
+	if err != nil {
+		return dt.NewErr(ErrFailedToResetEntry, "path", dir, err)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		info, err := b.Lstat(path)
+		if err != nil {
+			errs = append(errs, dt.NewErr(ErrFailedToResetEntry, "path", path, err))
+			continue
+		}
+		if _, ok := rf.pristineDirs[dt.DirPath(path)]; ok && info.IsDir() {
+			errs = dt.AppendErr(errs, rf.pruneUndeclared(b, path, files))
+			continue
+		}
+		ff, ok := files[path]
+		if ok && info.Mode().Type() == modeOfKind(ff.Kind).Type() {
+			continue
+		}
+		err = b.RemoveAll(path)
+		if err != nil {
+			errs = append(errs, dt.NewErr(ErrFailedToResetEntry, "path", path, err))
+		}
+	}
+	return dt.CombineErrs(errs)
+}
+
+// reset recreates the file if it is missing, rewrites its content if it has
+// changed, and applies its mode and times again if they differ.
+func (ff *FileFixture) reset(b Backend) (err error) {
+	var info os.FileInfo
+	var errs []error
+	var chmodded, rewritten bool
+
+	path := string(ff.Filepath)
+	mode := fileMode(ff.Permissions)
+
+	info, err = b.Lstat(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		err = ff.recreate(b)
+		goto end
+	case err != nil:
+		err = dt.NewErr(ErrFailedToResetEntry, "path", path, err)
+		goto end
+	}
+	if ff.Kind == RegularFileKind && !ff.unchanged(info) {
+		if info.Mode().Perm()&0600 != 0600 {
+			// Made owner-readable and writable to compare and rewrite content
+			errs = dt.AppendErr(errs, b.Chmod(path, info.Mode()&modeMask|0600))
+			chmodded = true
+		}
+		if !ff.sameContent(b, info) {
+			err = b.WriteFile(path, []byte(ff.Content), mode.Perm()|0200)
+			if err != nil {
+				errs = append(errs, dt.NewErr(ErrFailedToResetEntry, "path", path, err))
+			}
+			ff.recordSum()
+			rewritten = true
+		}
+	}
+	if chmodded || info.Mode()&modeMask != mode {
+		errs = dt.AppendErr(errs, chmodExact(b, dt.EntryPath(path), mode))
+	}
+	errs = dt.AppendErr(errs, ff.resetTimes(b, info, rewritten))
+	err = dt.CombineErrs(errs)
+	ff.recordStamp()
+end:
+	return err
+}
+
+// unchanged reports whether the file described by info is known to be as
+// created without reading it, i.e. its status change time is available and
+// it and the rest of its stamp are as recorded.
+func (ff *FileFixture) unchanged(info os.FileInfo) bool {
+	return ff.stamp.ctimeNsec != 0 && stampOf(info) == ff.stamp
+}
+
+// sameContent reports whether the file still holds the content it was
+// created with, comparing sizes before reading and hashing it.
+func (ff *FileFixture) sameContent(b Backend, info os.FileInfo) bool {
+	if info.Size() != ff.stamp.size {
+		return false
+	}
+	data, err := b.ReadFile(string(ff.Filepath))
+	return err == nil && sha256.Sum256(data) == ff.sum
+}
+
+// fileStamp identifies a version of a file's content without reading it.
+type fileStamp struct {
+	size      int64
+	mtimeSec  int64
+	mtimeNsec int
+	ctimeNsec int64  // Status change time in nanoseconds; zero where not available
+	inode     uint64 // Zero where not available
+}
+
+// stampOf returns the stamp of the file described by info.
+func stampOf(info os.FileInfo) fileStamp {
+	ctimeNsec, inode := changeStamp(info)
+	if ms, ok := info.Sys().(memSys); ok {
+		ctimeNsec, inode = ms.ctime.UnixNano(), ms.ino
+	}
+	return fileStamp{
+		size:      info.Size(),
+		mtimeSec:  info.ModTime().Unix(),
+		mtimeNsec: info.ModTime().Nanosecond(),
+		ctimeNsec: ctimeNsec,
+		inode:     inode,
+	}
+}
+
+// recordSum records the hash of the content the file is created with, for
+// Reset().
+func (ff *FileFixture) recordSum() {
+	ff.sum = sha256.Sum256([]byte(ff.Content))
+}
+
+// recordStamp records the stamp of the file as it now is, for Reset().
+func (ff *FileFixture) recordStamp() {
+	info, err := rootOf(ff.Parent).Backend().Lstat(string(ff.Filepath))
+	if err != nil || !info.Mode().IsRegular() {
+		ff.stamp = fileStamp{}
+		return
+	}
+	ff.stamp = stampOf(info)
+}
+
+// recreate creates the missing file again with its declared attributes.
+func (ff *FileFixture) recreate(b Backend) (err error) {
+	if ff.Kind != RegularFileKind {
+		err = ff.createSpecial()
+	} else {
+		err = b.WriteFile(string(ff.Filepath), []byte(ff.Content), fileMode(ff.Permissions).Perm()|0200)
+	}
+	if err != nil {
+		err = dt.NewErr(ErrFailedToResetEntry, "path", ff.Filepath, err)
+		goto end
+	}
+	err = ff.applyAttributes()
+	ff.recordSum()
+	ff.recordStamp()
+end:
+	return err
+}
+
+// resetTimes applies the file's declared times again if they were rewritten
+// along with its content or its modification time differs.
+func (ff *FileFixture) resetTimes(b Backend, info os.FileInfo, rewritten bool) error {
+	c := clockOf(ff.Parent)
+	_, mtime, err := c.resolve(ff.times())
+	switch {
+	case err != nil:
+		return dt.WithErr(err, "path", ff.Filepath)
+	case mtime.IsZero(), !rewritten && info.ModTime().Equal(mtime):
+		return nil
+	}
+	return c.apply(b, dt.EntryPath(ff.Filepath), ff.times())
+}
+
+// resetDir applies the mode of a directory again if it differs, and its times.
+func (rf *RootFixture) resetDir(b Backend, pd pendingDir) (err error) {
+	var info os.FileInfo
+	var errs []error
+
+	info, err = b.Lstat(string(pd.path))
+	if err != nil {
+		err = dt.NewErr(ErrFailedToResetEntry, "path", pd.path, err)
+		goto end
+	}
+	if !pd.keepMode && info.Mode()&modeMask != pd.mode {
+		errs = dt.AppendErr(errs, chmodExact(b, dt.EntryPath(pd.path), pd.mode))
+	}
+	// Directory times always change as entries are removed or recreated
+	errs = dt.AppendErr(errs, rf.clock.apply(b, dt.EntryPath(pd.path), pd.times))
+	err = dt.CombineErrs(errs)
+end:
+	return err
+}
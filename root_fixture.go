@@ -3,8 +3,12 @@
 package fsfix
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/mikeschinkel/go-dt"
 )
@@ -14,23 +18,43 @@ var _ Fixture = (*RootFixture)(nil)
 
 // RootFixture manages temporary directories and files for testing purposes.
 type RootFixture struct {
-	DirPrefix     string         // Prefix for temporary directory names
-	tempDir       dt.DirPath     // Path to the temporary directory
-	FileFixtures  []*FileFixture // File-level fixtures in the root temp directory
-	ChildFixtures []Fixture      // Project-level fixtures (directories with .git)
-	cleanupFunc   func()         // Function to clean up resources
-	created       bool
-	t             *testing.T
+	DirPrefix      string                    // Prefix for temporary directory names
+	BaseDir        dt.DirPath                // Directory to create the temporary directory in; OS temp dir if empty
+	BaseTime       time.Time                 // Base for fixture time offsets and default timestamp for the tree
+	tempDir        dt.DirPath                // Path to the temporary directory
+	FileFixtures   []*FileFixture            // File-level fixtures in the root temp directory
+	ChildFixtures  []Fixture                 // Project-level fixtures (directories with .git)
+	cleanupFunc    func() error              // Function to clean up resources
+	useTBTempDir   bool                      // Use t.TempDir() and let the testing package own removal
+	backend        Backend                   // Filesystem fixtures are created in; OSBackend if nil
+	clock          clock                     // Resolves fixture timestamps; set by Build()
+	pendingDirs    map[dt.DirPath]pendingDir // Directories to apply modes and times to once created
+	pathIndex      pathIndex                 // Paths claimed by fixtures, for duplicate and conflict detection
+	closers        []io.Closer               // Resources such as socket listeners held open until cleanup
+	deniedPaths    []deniedPath              // Entries whose access denials are verified once created
+	pendingWriters []*LiveWriter             // Live writers to start once the tree is complete
+	pristineDirs   map[dt.DirPath]pendingDir // Directories as finalized, restored by Reset()
+	forkOf         *RootFixture              // Created root whose file data Build() clones, for Fork()
+	created        bool
+	t              testing.TB
 }
 
 func (rf *RootFixture) RelativePath() dt.DirPath {
 	return "."
 }
 
+func (rf *RootFixture) parentFixture() Fixture {
+	return nil
+}
+
+func (rf *RootFixture) fixtureLabel() string {
+	return fmt.Sprintf("RootFixture '%s'", rf.DirPrefix)
+}
+
 // ensureCreated forces a failure if called before Create() is called.
 func (rf *RootFixture) ensureCreated() {
 	if !rf.created {
-		rf.t.Fatalf("RootFixture '%s' has not yet been created", rf.DirPrefix)
+		fatalf(rf.t, "RootFixture '%s' has not yet been created", rf.DirPrefix)
 	}
 }
 
@@ -41,73 +65,174 @@ func (rf *RootFixture) Dir() dt.DirPath {
 }
 
 // CreateWithParent is not applicable for RootFixture as it is the root of the fixture hierarchy.
-func (rf *RootFixture) createWithParent(*testing.T, Fixture) {
+func (rf *RootFixture) createWithParent(context.Context, Fixture) error {
 	panic("createWithParent is not relevant as RootFixture should be the root")
 }
 
 // Create creates the temporary directory and initializes all child fixtures and files.
-func (rf *RootFixture) Create(t *testing.T) {
+func (rf *RootFixture) Create(t testing.TB) {
 	t.Helper()
-	rf.created = true
-
-	// Create temp directory (this can fail, so it belongs in Create)
-	var err error
-	rf.tempDir, err = dt.MkdirTemp("", rf.DirPrefix+"-*")
+	rf.t = t
+	err := rf.Build(context.Background())
+	skipIfUnsupported(t, rf.fixtureLabel(), err)
 	if err != nil {
-		t.Errorf("Failed to create temp directory using '%s'; %v", rf.DirPrefix+"-*", err)
+		t.Errorf("Failed to create root fixture '%s'; %v", rf.DirPrefix, err)
 	}
+}
 
-	rf.cleanupFunc = func() {
-		err := rf.tempDir.RemoveAll()
-		if err != nil {
-			t.Errorf("Failed to remove temp directory '%s'; %v", rf.tempDir, err)
-		}
+// Build creates the temporary directory and initializes all child fixtures and
+// files without needing a testing.TB, so fixtures can be generated from CLIs,
+// `go generate` and demo programs. Failures are returned with path context
+// rather than being reported to a test.
+func (rf *RootFixture) Build(ctx context.Context) (err error) {
+	var errs []error
+
+	rf.created = true
+	rf.clock = newClock(rf.BaseTime)
+
+	// Create temp directory (this can fail, so it belongs in Build)
+	err = rf.makeTempDir()
+	if err != nil {
+		goto end
 	}
+	rf.recordDir(pendingDir{path: rf.tempDir, keepMode: true, declared: true})
 
 	// Set up all the project fixtures
 	// rf.RemoveFiles(t) // BUG: This removes the directory we just created
 	for _, cf := range rf.ChildFixtures {
-		cf.createWithParent(t, rf)
+		errs = dt.AppendErr(errs, cf.createWithParent(ctx, rf))
 	}
 
 	// Set up all the test fixture files (directly in temp directory)
 	for _, ff := range rf.FileFixtures {
-		ff.Create(t, rf)
+		errs = dt.AppendErr(errs, ff.create(ctx, rf))
+	}
+
+	// Directory modes and times go last as creating their children would
+	// change their times, and restrictive modes could prevent creating them
+	errs = dt.AppendErr(errs, rf.finalizeDirs())
+
+	// Denials can only be verified once their modes are in place
+	errs = dt.AppendErr(errs, rf.verifyDenials())
+	err = dt.CombineErrs(errs)
+	if err == nil {
+		rf.startWriters()
 	}
 
+end:
+	return err
+}
+
+// makeTempDir creates the temporary directory and sets how Cleanup()
+// removes it.
+func (rf *RootFixture) makeTempDir() (err error) {
+	var tempDir string
+
+	if rf.useTBTempDir && isOSBackend(rf.Backend()) {
+		// The testing package removes t.TempDir() itself, so cleanupFunc only
+		// closes kept-alive resources, before the removal as cleanups run LIFO.
+		rf.tempDir = dt.DirPath(rf.t.TempDir())
+		rf.BaseDir = rf.tempDir.Dir()
+		rf.cleanupFunc = rf.closeAll
+		rf.t.Cleanup(rf.Cleanup)
+		goto end
+	}
+
+	tempDir, err = rf.Backend().MkdirTemp(string(rf.BaseDir), rf.DirPrefix+"-*")
+	rf.tempDir = dt.DirPath(tempDir)
+	if err != nil {
+		err = dt.NewErr(ErrFailedToCreateTempDir, "base_dir", rf.BaseDir, "pattern", rf.DirPrefix+"-*", err)
+		goto end
+	}
+
+	rf.cleanupFunc = func() (err error) {
+		errs := dt.AppendErr(nil, rf.closeAll())
+		err = rf.Backend().RemoveAll(string(rf.tempDir))
+		if err != nil {
+			errs = append(errs, dt.NewErr(ErrFailedToRemoveTempDir, "path", rf.tempDir, err))
+		}
+		return dt.CombineErrs(errs)
+	}
+end:
+	return err
+}
+
+// RootFixtureArgs contains arguments for creating a RootFixture.
+type RootFixtureArgs struct {
+	BaseDir  dt.DirPath // Directory to create the temporary directory in, e.g. a tmpfs mount
+	BaseTime time.Time  // Base for fixture time offsets; when set every entry defaults to it
+	Backend  Backend    // Filesystem to create fixtures in; defaults to OSBackend, or use NewMemoryBackend()
 }
 
 // NewRootFixture creates a new TestFixture with the specified directory prefix.
 func NewRootFixture(dirPrefix string) *RootFixture {
+	return NewRootFixtureWithArgs(dirPrefix, nil)
+}
+
+// NewRootFixtureWithArgs creates a new TestFixture with the specified directory
+// prefix and arguments.
+func NewRootFixtureWithArgs(dirPrefix string, args *RootFixtureArgs) *RootFixture {
+	if args == nil {
+		args = &RootFixtureArgs{}
+	}
 	return &RootFixture{
 		DirPrefix:     dirPrefix,
+		BaseDir:       args.BaseDir,
+		BaseTime:      args.BaseTime,
+		backend:       args.Backend,
 		FileFixtures:  []*FileFixture{},
 		ChildFixtures: []Fixture{},
 	}
 }
 
+// NewRootFixtureInTempDir creates a new TestFixture rooted at t.TempDir().
+// The testing package owns removal of the directory, so Cleanup() is a no-op.
+func NewRootFixtureInTempDir(t testing.TB) *RootFixture {
+	t.Helper()
+	rf := NewRootFixture(t.Name())
+	rf.useTBTempDir = true
+	rf.t = t
+	return rf
+}
+
 // AddRepoFixture adds a project-level fixture (directory with .git) to the TestFixture.
-func (rf *RootFixture) AddRepoFixture(t *testing.T, name dt.PathSegments, args *RepoFixtureArgs) *RepoFixture {
+func (rf *RootFixture) AddRepoFixture(t testing.TB, name dt.PathSegments, args *RepoFixtureArgs) *RepoFixture {
 	pf := newRepoFixture(t, name, rf, args)
+	validateChild(t, rf, "RepoFixture", string(name), pf.AllowEscape)
+	registerChild(t, rf, "RepoFixture", string(name), pf, true, args != nil && args.Override)
+	adoptFiles(t, pf, pf.FileFixtures)
 	rf.ChildFixtures = append(rf.ChildFixtures, pf)
 	return pf
 }
 
 // AddDirFixture adds a directory fixture (directory with optional .git) to the TestFixture.
-func (rf *RootFixture) AddDirFixture(t *testing.T, name dt.PathSegments, args *DirFixtureArgs) *DirFixture {
+func (rf *RootFixture) AddDirFixture(t testing.TB, name dt.PathSegments, args *DirFixtureArgs) *DirFixture {
 	df := newDirFixture(t, name, rf, args)
 	df.Parent = rf
+	validateChild(t, rf, "DirFixture", string(name), df.AllowEscape)
+	registerChild(t, rf, "DirFixture", string(name), df, true, args != nil && args.Override)
+	adoptFiles(t, df, df.FileFixtures)
 	rf.ChildFixtures = append(rf.ChildFixtures, df)
 	return df
 }
 
 // AddFileFixture adds a file fixture directly to the TestFixture temp directory
-func (rf *RootFixture) AddFileFixture(t *testing.T, name dt.RelFilepath, args *FileFixtureArgs) *FileFixture {
+func (rf *RootFixture) AddFileFixture(t testing.TB, name dt.RelFilepath, args *FileFixtureArgs) *FileFixture {
 	ff := newFileFixture(t, name, rf, args)
+	validateChild(t, rf, "FileFixture", string(name), ff.AllowEscape)
+	registerChild(t, rf, "FileFixture", string(name), ff, false, args != nil && args.Override)
 	rf.FileFixtures = append(rf.FileFixtures, ff)
 	return ff
 }
 
+// Backend returns the filesystem fixtures are created in.
+func (rf *RootFixture) Backend() Backend {
+	if rf.backend == nil {
+		return OSBackend{}
+	}
+	return rf.backend
+}
+
 // TempDir returns the path to the temporary directory created for this fixture.
 func (rf *RootFixture) TempDir() dt.DirPath {
 	rf.ensureCreated()
@@ -115,15 +240,39 @@ func (rf *RootFixture) TempDir() dt.DirPath {
 }
 
 // Cleanup removes all temporary files and directories created by this fixture.
+// Failures are reported to the testing.TB passed to Create(), or logged when
+// the fixture was built via Build(). If the test has failed, the declared and
+// created trees are logged side by side first. It does nothing if the fixture
+// was never created, e.g. when a test is skipped while fixtures are being added.
 func (rf *RootFixture) Cleanup() {
-	rf.ensureCreated()
-	rf.cleanupFunc()
+	if rf.cleanupFunc == nil {
+		return
+	}
+	rf.logTreeOnFailure()
+	err := rf.cleanupFunc()
+	if err == nil {
+		return
+	}
+	if rf.t == nil {
+		dt.LogOnError(err)
+		return
+	}
+	rf.t.Errorf("Failed to clean up root fixture '%s'; %v", rf.DirPrefix, err)
+}
+
+// baseDir returns the directory the temporary directory was created in.
+func (rf *RootFixture) baseDir() dt.DirPath {
+	if rf.BaseDir == "" {
+		return dt.TempDir()
+	}
+	return rf.BaseDir
 }
 
 // RemoveFiles safely removes the temporary directory and all its contents.
-func (rf *RootFixture) RemoveFiles(t *testing.T) {
+// It refuses to remove anything not located inside the configured base directory.
+func (rf *RootFixture) RemoveFiles(t testing.TB) {
 	var err error
-	var tempDir, rootDir, tmpRoot dt.DirPath
+	var tempDir, rootDir, baseDir dt.DirPath
 	var rel dt.PathSegments
 
 	t.Helper()
@@ -151,40 +300,42 @@ func (rf *RootFixture) RemoveFiles(t *testing.T) {
 		goto end
 	}
 
-	// Work out the OS temp directory as a DirPath.
-	tmpRoot = dt.TempDir().Clean()
+	// Work out the configured base directory as an absolute DirPath.
+	baseDir, err = rf.baseDir().Abs()
+	if err != nil {
+		// If we can't reason about the base directory, do nothing.
+		goto end
+	}
 
-	// Never delete the entire temp rootDir either.
-	if tempDir == tmpRoot {
+	// Never delete the entire base directory either.
+	if tempDir == baseDir || baseDir == rootDir {
 		goto end
 	}
 
-	// Ensure tempDir is *inside* tmpRoot (not a sibling/parent).
-	// Adjust the type of rel / conversion if your Rel() returns a DirPath
-	// or some other type.
-	rel, err = tempDir.Rel(tmpRoot)
+	// Ensure tempDir is *inside* baseDir (not a sibling/parent).
+	rel, err = tempDir.Rel(baseDir)
 	if err != nil {
 		// If we can't reason about the relationship, do nothing.
 		goto end
 	}
 
 	if rel == "." {
-		// tempDir == tmpRoot (already guarded above, but belt-and-suspenders).
+		// tempDir == baseDir (already guarded above, but belt-and-suspenders).
 		goto end
 	}
 
 	if rel.HasDotDotPrefix() {
-		// tempDir is outside tmpRoot; refuse to delete.
+		// tempDir is outside baseDir; refuse to delete.
 		goto end
 	}
 
 	// At this point tempDir is:
 	// - absolute,
 	// - not a filesystem rootDir,
-	// - not the temp rootDir,
-	// - and is located *under* the temp rootDir.
+	// - not the base directory (nor is the base directory a filesystem rootDir),
+	// - and is located *under* the base directory.
 	// It's safe to remove.
-	err = rf.tempDir.RemoveAll()
+	err = rf.Backend().RemoveAll(string(rf.tempDir))
 	if err != nil {
 		t.Fatalf("failed to remove temporary files %q: %v", tempDir, err)
 	}
@@ -0,0 +1,40 @@
+//go:build linux
+
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// procFDBind returns a path to bind a socket for path at, via the open
+// directory in /proc/self/fd, so the socket is created in place. It returns
+// false if that path would still be too long.
+func procFDBind(path string) (bind socketBind, ok bool, err error) {
+	var dir *os.File
+
+	dir, err = os.Open(filepath.Dir(path))
+	if err != nil {
+		goto end
+	}
+	bind = socketBind{
+		path:    fmt.Sprintf("/proc/self/fd/%d/%s", dir.Fd(), filepath.Base(path)),
+		release: dir.Close,
+	}
+	if len(bind.path) > maxSocketPath {
+		err = dir.Close()
+		goto end
+	}
+	_, err = os.Stat(filepath.Dir(bind.path))
+	if err != nil {
+		// Without /proc, e.g. in a minimal container
+		err = dir.Close()
+		goto end
+	}
+	ok = true
+end:
+	return bind, ok, err
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+// procFDBind reports that /proc/self/fd is not available on this platform.
+func procFDBind(string) (socketBind, bool, error) {
+	return socketBind{}, false, nil
+}
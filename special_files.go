@@ -0,0 +1,206 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// FileKind is the type of filesystem entry a FileFixture creates.
+type FileKind int
+
+const (
+	RegularFileKind FileKind = iota // A regular file with Content; the default
+	NamedPipeKind                   // A named pipe, as made by mkfifo
+	SocketKind                      // A listening Unix domain socket, kept open until cleanup
+	CharDeviceKind                  // A character device node; skips the test if mknod is not permitted
+	BlockDeviceKind                 // A block device node; skips the test if mknod is not permitted
+)
+
+func (k FileKind) String() string {
+	switch k {
+	case RegularFileKind:
+		return "regular file"
+	case NamedPipeKind:
+		return "named pipe"
+	case SocketKind:
+		return "socket"
+	case CharDeviceKind:
+		return "character device"
+	case BlockDeviceKind:
+		return "block device"
+	}
+	return "unknown"
+}
+
+// maxSocketPath is the longest socket path bound directly; sun_path holds
+// 108 bytes on Linux but only 104 on macOS and the BSDs, including the NUL.
+const maxSocketPath = 103
+
+// createSpecial creates the non-regular file ff describes at ff.Filepath.
+func (ff *FileFixture) createSpecial() (err error) {
+	var l *net.UnixListener
+
+	switch ff.Kind {
+	case NamedPipeKind:
+		err = mkfifo(string(ff.Filepath), fileMode(ff.Permissions).Perm()|0200)
+	case CharDeviceKind, BlockDeviceKind:
+		err = mknod(string(ff.Filepath), ff.Kind, fileMode(ff.Permissions).Perm()|0200, ff.DeviceMajor, ff.DeviceMinor)
+		if errors.Is(err, syscall.EPERM) {
+			err = dt.NewErr(ErrDeviceNodesNotPermitted, "path", ff.Filepath, "kind", ff.Kind, err)
+			goto end
+		}
+	case SocketKind:
+		l, err = listenUnix(string(ff.Filepath))
+		if err == nil {
+			rootOf(ff.Parent).keepAlive(l)
+		}
+	default:
+		err = dt.NewErr(ErrUnknownFileKind, "path", ff.Filepath, "kind", int(ff.Kind))
+		goto end
+	}
+	if errors.Is(err, errors.ErrUnsupported) {
+		err = dt.NewErr(ErrFileKindUnsupported, "path", ff.Filepath, "kind", ff.Kind, err)
+		goto end
+	}
+	if err != nil {
+		err = dt.NewErr(ErrFailedToCreateFile, "path", ff.Filepath, "kind", ff.Kind, err)
+	}
+end:
+	return err
+}
+
+// listenUnix listens on a Unix domain socket at path, accepting and closing
+// connections until closed. Paths too long for sun_path are bound via a
+// shorter path to the same directory, or else in a short temporary directory
+// on the same filesystem and renamed into place.
+func listenUnix(path string) (l *net.UnixListener, err error) {
+	var bind socketBind
+
+	bind.path = path
+	if len(path) > maxSocketPath {
+		bind, err = shortSocketBind(path)
+		if err != nil {
+			goto end
+		}
+		defer func() {
+			err = dt.CombineErrs([]error{err, bind.release()})
+			if err != nil && l != nil {
+				// The caller only keeps the listener alive on success
+				err = dt.CombineErrs([]error{err, l.Close()})
+				l = nil
+			}
+		}()
+	}
+	l, err = net.ListenUnix("unix", &net.UnixAddr{Name: bind.path, Net: "unix"})
+	if err != nil {
+		goto end
+	}
+	if bind.path != path {
+		// Connections resolve the path to the socket inode, so it still
+		// answers at path; bind.path may not lead there once released.
+		l.SetUnlinkOnClose(false)
+	}
+	if bind.rename {
+		err = os.Rename(bind.path, path)
+		if errors.Is(err, syscall.EXDEV) {
+			err = dt.NewErr(ErrSocketPathTooLong, "path", path, "fallback", bind.path, "reason", "fallback is on another filesystem", err)
+		}
+		if err != nil {
+			err = dt.CombineErrs([]error{err, l.Close()})
+			l = nil
+			goto end
+		}
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+end:
+	return l, err
+}
+
+// socketBind is where a socket for a path too long for sun_path is bound.
+type socketBind struct {
+	path    string       // Short path to bind
+	rename  bool         // The socket must be renamed from path into place
+	release func() error // Releases what was acquired to bind at path
+}
+
+// shortSocketBind returns a short path to bind a socket for path at.
+func shortSocketBind(path string) (bind socketBind, err error) {
+	var ok bool
+	var dir, tmp string
+
+	bind, ok, err = procFDBind(path)
+	if ok || err != nil {
+		goto end
+	}
+
+	// The shallowest writable ancestor on the same filesystem gives the
+	// shortest path that can be renamed into place
+	dir = filepath.Dir(path)
+	for _, anc := range ancestors(dir) {
+		if !sameDevice(anc, dir) {
+			continue
+		}
+		tmp, err = os.MkdirTemp(anc, "fsfix-")
+		if err != nil {
+			err = nil
+			continue
+		}
+		bind = socketBind{
+			path:    filepath.Join(tmp, "s"),
+			rename:  true,
+			release: func() error { return os.RemoveAll(tmp) },
+		}
+		if len(bind.path) > maxSocketPath {
+			_ = bind.release()
+			err = dt.NewErr(ErrSocketPathTooLong, "path", path, "fallback", bind.path)
+		}
+		goto end
+	}
+	err = dt.NewErr(ErrSocketPathTooLong, "path", path, "reason", "no writable directory for a shorter path on the same filesystem")
+end:
+	return bind, err
+}
+
+// ancestors returns dir and the directories above it, shallowest first.
+func ancestors(dir string) (dirs []string) {
+	for {
+		dirs = append([]string{dir}, dirs...)
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dirs
+		}
+		dir = parent
+	}
+}
+
+// keepAlive holds c open until the fixture is cleaned up.
+func (rf *RootFixture) keepAlive(c io.Closer) {
+	rf.closers = append(rf.closers, c)
+}
+
+// closeAll closes everything kept alive by the fixture, newest first.
+func (rf *RootFixture) closeAll() error {
+	var errs []error
+
+	for i := len(rf.closers) - 1; i >= 0; i-- {
+		errs = dt.AppendErr(errs, rf.closers[i].Close())
+	}
+	rf.closers = nil
+	return dt.CombineErrs(errs)
+}
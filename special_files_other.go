@@ -0,0 +1,23 @@
+//go:build !unix
+
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// mkfifo reports that named pipes are only supported on Unix.
+func mkfifo(path string, _ os.FileMode) error {
+	return &fs.PathError{Op: "mkfifo", Path: path, Err: errors.ErrUnsupported}
+}
+
+// sameDevice reports whether a and b are on the same volume.
+func sameDevice(a, b string) bool {
+	return strings.EqualFold(filepath.VolumeName(a), filepath.VolumeName(b))
+}
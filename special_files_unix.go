@@ -0,0 +1,30 @@
+//go:build unix
+
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// mkfifo creates a named pipe at path.
+func mkfifo(path string, perm os.FileMode) (err error) {
+	err = syscall.Mkfifo(path, uint32(perm))
+	if err != nil {
+		err = &fs.PathError{Op: "mkfifo", Path: path, Err: err}
+	}
+	return err
+}
+
+// sameDevice reports whether a and b are on the same filesystem.
+func sameDevice(a, b string) bool {
+	var sa, sb syscall.Stat_t
+
+	if syscall.Stat(a, &sa) != nil || syscall.Stat(b, &sb) != nil {
+		return false
+	}
+	return sa.Dev == sb.Dev
+}
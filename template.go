@@ -0,0 +1,199 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"maps"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// Template declares a reusable subtree of files, directories and
+// repositories without a parent, to be instantiated with Mount() wherever
+// the same layout is needed. Names and static Content may use text/template
+// actions such as {{.service}}, expanded with the variables of each mount;
+// a ContentFunc finds them in FileFixture.Vars, merged over any Vars the
+// template declared for the file.
+type Template struct {
+	Name string       // Name used in failure messages
+	root *RootFixture // Holds the declarations; never created
+}
+
+// NewTemplate returns an empty Template named name.
+func NewTemplate(name string) *Template {
+	return &Template{
+		Name: name,
+		root: NewRootFixture(name),
+	}
+}
+
+// AddFileFixture adds a file to the top level of the template.
+func (tp *Template) AddFileFixture(t testing.TB, name dt.RelFilepath, args *FileFixtureArgs) *FileFixture {
+	return tp.root.AddFileFixture(t, name, args)
+}
+
+// AddDirFixture adds a directory to the top level of the template. Add its
+// contents via the returned DirFixture.
+func (tp *Template) AddDirFixture(t testing.TB, name dt.PathSegments, args *DirFixtureArgs) *DirFixture {
+	return tp.root.AddDirFixture(t, name, args)
+}
+
+// AddRepoFixture adds a repository to the top level of the template. Add
+// its contents via the returned RepoFixture.
+func (tp *Template) AddRepoFixture(t testing.TB, name dt.PathSegments, args *RepoFixtureArgs) *RepoFixture {
+	return tp.root.AddRepoFixture(t, name, args)
+}
+
+// Mount declares the fixtures of tmpl beneath at, relative to the root, with
+// vars available to their names and content, and returns the top-level
+// fixtures it declared. Use "." for at to mount directly in the root.
+func (rf *RootFixture) Mount(t testing.TB, at dt.PathSegments, tmpl *Template, vars map[string]any) []Node {
+	return mountTemplate(t, rf, at, tmpl, vars)
+}
+
+// Mount declares the fixtures of tmpl beneath at, relative to this
+// directory. See RootFixture.Mount().
+func (df *DirFixture) Mount(t testing.TB, at dt.PathSegments, tmpl *Template, vars map[string]any) []Node {
+	return mountTemplate(t, df, at, tmpl, vars)
+}
+
+// Mount declares the fixtures of tmpl beneath at, relative to this
+// repository. See RootFixture.Mount().
+func (rf *RepoFixture) Mount(t testing.TB, at dt.PathSegments, tmpl *Template, vars map[string]any) []Node {
+	return mountTemplate(t, rf, at, tmpl, vars)
+}
+
+// templateMount instantiates a Template with the variables of one mount.
+type templateMount struct {
+	t    testing.TB
+	tmpl *Template
+	vars map[string]any
+}
+
+// mountTemplate declares the fixtures of tmpl in parent beneath at.
+func mountTemplate(t testing.TB, parent fixtureAdder, at dt.PathSegments, tmpl *Template, vars map[string]any) []Node {
+	if t != nil {
+		t.Helper()
+	}
+	m := templateMount{t: t, tmpl: tmpl, vars: vars}
+	return m.declare(parent, string(at), tmpl.root.FileFixtures, tmpl.root.ChildFixtures)
+}
+
+// declare adds copies of files and children to parent, prefixing their
+// names with at, and returns the fixtures added.
+func (m templateMount) declare(parent fixtureAdder, at string, files []*FileFixture, children []Fixture) (nodes []Node) {
+	for _, ff := range files {
+		args := ff.mountArgs()
+		if ff.ContentFunc == nil {
+			args.Content = m.expand(ff.fixtureLabel()+" content", ff.Content)
+		}
+		args.Vars = maps.Clone(ff.Vars)
+		if args.Vars == nil {
+			args.Vars = make(map[string]any, len(m.vars))
+		}
+		maps.Copy(args.Vars, m.vars)
+		name := m.name(at, ff.fixtureLabel(), string(ff.Name))
+		nodes = append(nodes, parent.AddFileFixture(m.t, dt.RelFilepath(name), args))
+	}
+	for _, child := range children {
+		var mounted fixtureAdder
+		var src *DirFixture
+
+		switch f := child.(type) {
+		case *DirFixture:
+			src = f
+			name := m.name(at, f.fixtureLabel(), string(f.Name))
+			mounted = parent.AddDirFixture(m.t, dt.PathSegments(name), f.mountArgs())
+		case *RepoFixture:
+			src = f.DirFixture
+			name := m.name(at, f.fixtureLabel(), string(f.Name))
+			mounted = parent.AddRepoFixture(m.t, dt.PathSegments(name), &RepoFixtureArgs{
+				Permissions:    f.Permissions,
+				ModifiedTime:   f.ModifiedTime,
+				AccessedTime:   f.AccessedTime,
+				ModifiedOffset: f.ModifiedOffset,
+				AccessedOffset: f.AccessedOffset,
+				AllowEscape:    f.AllowEscape,
+			})
+		default:
+			continue
+		}
+		m.declare(mounted, "", src.FileFixtures, src.ChildFixtures)
+		nodes = append(nodes, mounted)
+	}
+	return nodes
+}
+
+// name returns the expanded name of the fixture labelled label, beneath at.
+func (m templateMount) name(at, label, name string) string {
+	name = m.expand(label+" name", name)
+	if at == "" || at == "." {
+		return name
+	}
+	return filepath.Join(at, name)
+}
+
+// expand executes text as a text/template with the mount's variables,
+// failing the test if it cannot, e.g. when a variable is missing.
+func (m templateMount) expand(what, text string) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	sb := strings.Builder{}
+	tt, err := template.New(what).Option("missingkey=error").Parse(text)
+	if err == nil {
+		err = tt.Execute(&sb, m.vars)
+	}
+	if err != nil {
+		fatalf(m.t, "Failed to expand %s of Template '%s'; %v", what, m.tmpl.Name, err)
+	}
+	return sb.String()
+}
+
+// mountArgs returns the FileFixtureArgs that declare a copy of ff.
+func (ff *FileFixture) mountArgs() *FileFixtureArgs {
+	return &FileFixtureArgs{
+		Kind:           ff.Kind,
+		Content:        ff.Content,
+		ContentFunc:    ff.ContentFunc,
+		Vars:           ff.Vars,
+		DeviceMajor:    ff.DeviceMajor,
+		DeviceMinor:    ff.DeviceMinor,
+		ModifiedTime:   ff.ModifiedTime,
+		AccessedTime:   ff.AccessedTime,
+		ModifiedOffset: ff.ModifiedOffset,
+		AccessedOffset: ff.AccessedOffset,
+		Permissions:    ff.Permissions,
+		DirPermissions: ff.DirPermissions,
+		Deny:           ff.Deny,
+		Owner:          ff.Owner,
+		Group:          ff.Group,
+		XAttrs:         maps.Clone(ff.XAttrs),
+		Lock:           clonePtr(ff.Lock),
+		HoldOpen:       clonePtr(ff.HoldOpen),
+		Writer:         clonePtr(ff.Writer),
+		DoNotCreate:    ff.DoNotCreate,
+		AllowEscape:    ff.AllowEscape,
+	}
+}
+
+// mountArgs returns the DirFixtureArgs that declare a copy of df, without
+// its contents.
+func (df *DirFixture) mountArgs() *DirFixtureArgs {
+	return &DirFixtureArgs{
+		Permissions:    df.Permissions,
+		Deny:           df.Deny,
+		Owner:          df.Owner,
+		Group:          df.Group,
+		XAttrs:         maps.Clone(df.XAttrs),
+		ModifiedTime:   df.ModifiedTime,
+		AccessedTime:   df.AccessedTime,
+		ModifiedOffset: df.ModifiedOffset,
+		AccessedOffset: df.AccessedOffset,
+		AllowEscape:    df.AllowEscape,
+	}
+}
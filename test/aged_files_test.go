@@ -0,0 +1,70 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestAddAgedFileFixtures(t *testing.T) {
+	now := time.Date(2025, 3, 10, 2, 0, 0, 0, time.UTC)
+
+	tf := fsfix.NewRootFixture("aged-files")
+	defer tf.Cleanup()
+	df := tf.AddDirFixture(t, "backups", nil)
+	ffs := df.AddAgedFileFixtures(t, &fsfix.AgedFileFixturesArgs{
+		NameLayout: "backup-2006-01-02.tar",
+		Now:        now,
+		MaxAge:     4 * 24 * time.Hour,
+		Gaps:       []int{2},
+		Duplicates: map[int]int{1: 1},
+		File: &fsfix.FileFixtureArgs{
+			Content: "backup",
+		},
+	})
+	tf.Create(t)
+
+	want := []struct {
+		name  dt.Filename
+		mtime time.Time
+	}{
+		{"backup-2025-03-10.tar", now},
+		{"backup-2025-03-09.tar", now.Add(-24 * time.Hour)},
+		{"backup-2025-03-09-1.tar", now.Add(-24*time.Hour + time.Minute)},
+		{"backup-2025-03-07.tar", now.Add(-3 * 24 * time.Hour)},
+		{"backup-2025-03-06.tar", now.Add(-4 * 24 * time.Hour)},
+	}
+	if len(ffs) != len(want) {
+		t.Fatalf("AddAgedFileFixtures(): want %d files, got %d", len(want), len(ffs))
+	}
+	for i, w := range want {
+		if ffs[i].Filepath.Base() != w.name {
+			t.Errorf("File #%d: want name '%s', got '%s'", i, w.name, ffs[i].Filepath.Base())
+		}
+		assertModTime(t, dt.EntryPath(ffs[i].Filepath), w.mtime)
+	}
+}
+
+func TestAddAgedFileFixturesLeavesArgsAlone(t *testing.T) {
+	tf := fsfix.NewRootFixture("aged-files")
+	defer tf.Cleanup()
+
+	args := &fsfix.AgedFileFixturesArgs{
+		NameLayout: "app-2006-01-02.log",
+		Now:        time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		MaxAge:     48 * time.Hour,
+	}
+	tf.AddDirFixture(t, "logs", nil).AddAgedFileFixtures(t, args)
+	if args.Interval != 0 {
+		t.Errorf("Interval after AddAgedFileFixtures(): want the caller's 0, got %s", args.Interval)
+	}
+
+	// The same args reused with a longer Interval yields a shorter series
+	args.Interval = 48 * time.Hour
+	ffs := tf.AddDirFixture(t, "archive", nil).AddAgedFileFixtures(t, args)
+	if len(ffs) != 2 {
+		t.Errorf("AddAgedFileFixtures() with a 48h Interval: want 2 files, got %d", len(ffs))
+	}
+}
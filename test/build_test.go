@@ -0,0 +1,59 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestBuildWithoutTestingTB(t *testing.T) {
+	tf := fsfix.NewRootFixture("build-test")
+
+	df := tf.AddDirFixture(nil, "data", nil)
+	ff := df.AddFileFixture(nil, "hello.txt", &fsfix.FileFixtureArgs{
+		Content: "hello",
+	})
+
+	err := tf.Build(context.Background())
+	if err != nil {
+		t.Fatalf("Build() returned an error: %v", err)
+	}
+	defer tf.Cleanup()
+
+	gotBB, err := dt.ReadFile(ff.Filepath)
+	if err != nil {
+		t.Fatalf("Failed to read %s; %v", ff.Filepath, err)
+	}
+	if string(gotBB) != "hello" {
+		t.Errorf("FileFixture content: want 'hello', got '%s'", string(gotBB))
+	}
+}
+
+func TestBuildCancelled(t *testing.T) {
+	tf := fsfix.NewRootFixture("build-cancelled")
+	tf.AddFileFixture(nil, "never.txt", nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := tf.Build(ctx)
+	defer tf.Cleanup()
+	if !errors.Is(err, fsfix.ErrFixtureBuildCancelled) {
+		t.Errorf("Build() with cancelled context: want ErrFixtureBuildCancelled, got %v", err)
+	}
+}
+
+func BenchmarkRootFixtureCreate(b *testing.B) {
+	for b.Loop() {
+		tf := fsfix.NewRootFixture("bench")
+		df := tf.AddDirFixture(b, "src", nil)
+		df.AddFileFixture(b, "main.go", &fsfix.FileFixtureArgs{
+			Content: "package main\n",
+		})
+		tf.Create(b)
+		tf.Cleanup()
+	}
+}
@@ -0,0 +1,131 @@
+package test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+// declareProject declares the standard project variants are derived from.
+func declareProject(t *testing.T) *fsfix.RootFixture {
+	t.Helper()
+	tf := fsfix.NewRootFixture("project")
+	rf := tf.AddRepoFixture(t, "repo", nil)
+	rf.AddFileFixture(t, "go.mod", &fsfix.FileFixtureArgs{Content: "module example.com/repo"})
+	rf.AddFileFixture(t, "main.go", &fsfix.FileFixtureArgs{Content: "package main"})
+	df := rf.AddDirFixture(t, "config", nil)
+	df.AddFileFixture(t, "app.yaml", &fsfix.FileFixtureArgs{
+		Content: "debug: false",
+	})
+	return tf
+}
+
+func TestCloneVariants(t *testing.T) {
+	base := declareProject(t)
+	baseTree := base.String()
+
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, tf *fsfix.RootFixture)
+		check  func(t *testing.T, tf *fsfix.RootFixture)
+	}{
+		{
+			name: "missing go.mod",
+			modify: func(t *testing.T, tf *fsfix.RootFixture) {
+				tf.Remove(t, "repo/go.mod")
+			},
+			check: func(t *testing.T, tf *fsfix.RootFixture) {
+				if tf.Lookup("repo/go.mod") != nil || fileExists(t, dt.FilepathJoin(tf.Dir(), "repo/go.mod")) {
+					t.Errorf("repo/go.mod: want removed")
+				}
+			},
+		},
+		{
+			name: "without config",
+			modify: func(t *testing.T, tf *fsfix.RootFixture) {
+				tf.Remove(t, "repo/config")
+			},
+			check: func(t *testing.T, tf *fsfix.RootFixture) {
+				if tf.Lookup("repo/config/app.yaml") != nil {
+					t.Errorf("repo/config/app.yaml: want removed with its directory")
+				}
+			},
+		},
+		{
+			name: "debug config",
+			modify: func(t *testing.T, tf *fsfix.RootFixture) {
+				tf.ReplaceFile(t, "repo/config/app.yaml", &fsfix.FileFixtureArgs{Content: "debug: true"})
+			},
+			check: func(t *testing.T, tf *fsfix.RootFixture) {
+				ff := tf.Lookup("repo/config/app.yaml").(*fsfix.FileFixture)
+				content, _ := dt.ReadFile(ff.Filepath)
+				if string(content) != "debug: true" {
+					t.Errorf("repo/config/app.yaml: want %q, got %q", "debug: true", content)
+				}
+			},
+		},
+		{
+			name: "read-only main.go",
+			modify: func(t *testing.T, tf *fsfix.RootFixture) {
+				tf.SetPermissions(t, "repo/main.go", 0444)
+			},
+			check: func(t *testing.T, tf *fsfix.RootFixture) {
+				ff := tf.Lookup("repo/main.go").(*fsfix.FileFixture)
+				info, err := os.Stat(string(ff.Filepath))
+				if err != nil || info.Mode().Perm() != 0444 {
+					t.Errorf("repo/main.go: want mode 0444, got %v (%v)", info, err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf := base.Clone()
+			tt.modify(t, tf)
+			tf.Create(t)
+			defer tf.Cleanup()
+			tt.check(t, tf)
+		})
+	}
+
+	if got := base.String(); got != baseTree {
+		t.Errorf("Base declaration changed by variants:\nwant:\n%s\ngot:\n%s", baseTree, got)
+	}
+}
+
+func TestCloneRewiresParents(t *testing.T) {
+	base := declareProject(t)
+	clone := base.Clone()
+	err := clone.Walk(func(n fsfix.Node) error {
+		if base.Lookup(nodePath(t, n)) == n {
+			t.Errorf("%s: shared with the original", nodePath(t, n))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk(); %v", err)
+	}
+	ff := clone.Lookup("repo/config/app.yaml").(*fsfix.FileFixture)
+	df := clone.Lookup("repo/config").(*fsfix.DirFixture)
+	rf := clone.Lookup("repo").(*fsfix.RepoFixture)
+	if ff.Parent != fsfix.Fixture(df) || df.Parent != fsfix.Fixture(rf) || rf.Parent != fsfix.Fixture(clone) {
+		t.Errorf("Parent links not rewired to the clone")
+	}
+
+	// The clone keeps detecting duplicates declared against the original
+	msg := expectFatal(t, func(tb testing.TB) {
+		clone.AddFileFixture(tb, "repo/go.mod", nil)
+	})
+	if !strings.Contains(msg, "duplicates") {
+		t.Errorf("Duplicate in clone: want fatal, got %q", msg)
+	}
+	msg = expectFatal(t, func(tb testing.TB) {
+		clone.Remove(tb, "repo/missing.txt")
+	})
+	if msg == "" {
+		t.Errorf("Remove() of undeclared path: want fatal, got none")
+	}
+}
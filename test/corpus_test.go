@@ -111,6 +111,9 @@ func runFileFixtureArgsCorpus(t *testing.T, data []byte) {
 			permissions = 0644
 		}
 
+		if fsfix.ValidateFixtureName(name) != nil {
+			return
+		}
 		rf := fsfix.NewRootFixture("corpus-test")
 		_ = rf.AddFileFixture(t, dt.RelFilepath(name), &fsfix.FileFixtureArgs{
 			Content:     "test content",
@@ -0,0 +1,122 @@
+//go:build unix
+
+package test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestAccessDenialPresets(t *testing.T) {
+	tf := fsfix.NewRootFixture("denials")
+	defer tf.Cleanup()
+
+	// Skips the test when running as root
+	unreadable := tf.AddFileFixture(t, "secret.txt", &fsfix.FileFixtureArgs{
+		Deny: fsfix.UnreadableFile,
+	})
+	writeOnly := tf.AddFileFixture(t, "drop.log", &fsfix.FileFixtureArgs{
+		Deny: fsfix.WriteOnlyFile,
+	})
+	unlistable := tf.AddDirFixture(t, "unlistable", &fsfix.DirFixtureArgs{
+		Deny: fsfix.UnlistableDir,
+	})
+	known := unlistable.AddFileFixture(t, "known.txt", &fsfix.FileFixtureArgs{
+		Content: "found by name",
+	})
+	untraversable := tf.AddDirFixture(t, "untraversable", &fsfix.DirFixtureArgs{
+		Deny: fsfix.UntraversableDir,
+	})
+	untraversable.AddFileFixture(t, "listed.txt", nil)
+	readOnly := tf.AddDirFixture(t, "readonly", &fsfix.DirFixtureArgs{
+		Deny: fsfix.ReadOnlyDir,
+	})
+	tf.Create(t)
+
+	assertMode(t, dt.EntryPath(unreadable.Filepath), 0000)
+	assertMode(t, dt.EntryPath(writeOnly.Filepath), 0200)
+	assertMode(t, dt.EntryPath(unlistable.Dir()), os.ModeDir|0311)
+	assertMode(t, dt.EntryPath(untraversable.Dir()), os.ModeDir|0644)
+	assertMode(t, dt.EntryPath(readOnly.Dir()), os.ModeDir|0555)
+
+	_, err := os.ReadFile(string(unreadable.Filepath))
+	assertPermissionDenied(t, "reading unreadable file", err)
+	err = os.WriteFile(string(writeOnly.Filepath), []byte("appended"), 0)
+	if err != nil {
+		t.Errorf("Writing write-only file: want success, got %v", err)
+	}
+	_, err = os.ReadDir(string(unlistable.Dir()))
+	assertPermissionDenied(t, "listing unlistable dir", err)
+	content, err := os.ReadFile(string(known.Filepath))
+	if err != nil || string(content) != "found by name" {
+		t.Errorf("Reading known file in unlistable dir: want 'found by name', got '%s' (%v)", content, err)
+	}
+	f, err := os.Open(string(untraversable.Dir()))
+	if err != nil {
+		t.Fatalf("Failed to open untraversable dir; %v", err)
+	}
+	names, err := f.Readdirnames(-1)
+	_ = f.Close()
+	if err != nil || strings.Join(names, ",") != "listed.txt" {
+		t.Errorf("Listing untraversable dir: want 'listed.txt', got %v (%v)", names, err)
+	}
+	_, err = os.Stat(filepath.Join(string(untraversable.Dir()), "listed.txt"))
+	assertPermissionDenied(t, "traversing untraversable dir", err)
+	err = os.WriteFile(filepath.Join(string(readOnly.Dir()), "new.txt"), nil, 0644)
+	assertPermissionDenied(t, "creating file in read-only dir", err)
+}
+
+func TestAccessDenialMismatch(t *testing.T) {
+	tf := fsfix.NewRootFixture("denial-mismatch")
+	msg := expectFatal(t, func(tb testing.TB) {
+		tf.AddDirFixture(tb, "dir", &fsfix.DirFixtureArgs{
+			Deny: fsfix.UnreadableFile,
+		})
+	})
+	if !strings.Contains(msg, "'unreadable file' cannot be applied") {
+		t.Errorf("Expected mismatched denial to be fatal, got %q", msg)
+	}
+}
+
+func assertPermissionDenied(t *testing.T, op string, err error) {
+	t.Helper()
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("%s: want permission denied, got %v", op, err)
+	}
+}
+
+func TestForkOfDeniedEntries(t *testing.T) {
+	tf := fsfix.NewRootFixture("denials")
+	defer tf.Cleanup()
+
+	// Skips the test when running as root
+	tf.AddFileFixture(t, "secret.txt", &fsfix.FileFixtureArgs{
+		Content: "secret",
+		Deny:    fsfix.UnreadableFile,
+	})
+	untraversable := tf.AddDirFixture(t, "untraversable", &fsfix.DirFixtureArgs{
+		Deny: fsfix.UntraversableDir,
+	})
+	untraversable.AddFileFixture(t, "listed.txt", &fsfix.FileFixtureArgs{Content: "listed"})
+	tf.Create(t)
+
+	fork := tf.Fork(t)
+	defer fork.Cleanup()
+	secret := fork.Lookup("secret.txt").(*fsfix.FileFixture)
+	listed := fork.Lookup("untraversable/listed.txt").(*fsfix.FileFixture)
+	assertMode(t, dt.EntryPath(secret.Filepath), 0000)
+	assertMode(t, dt.EntryPath(fork.Lookup("untraversable").(*fsfix.DirFixture).Dir()), os.ModeDir|0644)
+
+	// Denied originals cannot be cloned, so their declared content is used
+	mustDo(t, os.Chmod(string(secret.Filepath), 0600))
+	mustDo(t, os.Chmod(filepath.Dir(string(listed.Filepath)), 0755))
+	assertFileContent(t, fsfix.OSBackend{}, string(secret.Filepath), "secret")
+	assertFileContent(t, fsfix.OSBackend{}, string(listed.Filepath), "listed")
+}
@@ -0,0 +1,52 @@
+package test
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestFixtureFS(t *testing.T) {
+	tf := fsfix.NewRootFixture("fixture-fs")
+	defer tf.Cleanup()
+
+	tf.AddFileFixture(t, "README.md", &fsfix.FileFixtureArgs{Content: "# Readme"})
+	rf := tf.AddRepoFixture(t, "repo", nil)
+	rf.AddFileFixture(t, "go.mod", &fsfix.FileFixtureArgs{Content: "module example.com/repo"})
+	rf.AddFileFixture(t, "cmd/tool/main.go", &fsfix.FileFixtureArgs{Content: "package main"})
+	df := tf.AddDirFixture(t, "docs", nil)
+	df.AddFileFixture(t, "guide/intro.md", &fsfix.FileFixtureArgs{Content: "Intro"})
+	df.AddFileFixture(t, "guide/missing.md", &fsfix.FileFixtureArgs{DoNotCreate: true})
+	tf.Create(t)
+
+	err := fstest.TestFS(tf.FS(), declaredFiles(t, tf)...)
+	if err != nil {
+		t.Errorf("RootFixture.FS(); %v", err)
+	}
+	err = fstest.TestFS(rf.FS(), declaredFiles(t, rf)...)
+	if err != nil {
+		t.Errorf("RepoFixture.FS(); %v", err)
+	}
+	err = fstest.TestFS(df.FS(), declaredFiles(t, df)...)
+	if err != nil {
+		t.Errorf("DirFixture.FS(); %v", err)
+	}
+
+	sub, err := fs.Sub(tf.FS(), "docs/guide")
+	if err != nil {
+		t.Fatalf("fs.Sub(); %v", err)
+	}
+	if _, ok := sub.(*fsfix.FixtureFS); !ok {
+		t.Errorf("fs.Sub(): want *fsfix.FixtureFS, got %T", sub)
+	}
+	content, err := fs.ReadFile(sub, "intro.md")
+	if err != nil || string(content) != "Intro" {
+		t.Errorf("ReadFile() via Sub(): want 'Intro', got '%s' (%v)", content, err)
+	}
+	_, err = tf.FS().Sub("../escape")
+	if err == nil {
+		t.Errorf("Sub() with invalid path: want error, got nil")
+	}
+}
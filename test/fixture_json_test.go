@@ -0,0 +1,180 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+// declareJSONTree declares a tree exercising most encoded fields.
+func declareJSONTree(t *testing.T) *fsfix.RootFixture {
+	t.Helper()
+	tf := fsfix.NewRootFixtureWithArgs("json", &fsfix.RootFixtureArgs{
+		BaseTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	tf.AddFileFixture(t, "README.md", &fsfix.FileFixtureArgs{
+		Content: "# Readme",
+		Vars:    map[string]any{"project": "demo", "port": 8080},
+	})
+	tf.AddFileFixture(t, "missing.txt", &fsfix.FileFixtureArgs{DoNotCreate: true})
+	rf := tf.AddRepoFixture(t, "repo", &fsfix.RepoFixtureArgs{ModifiedOffset: "-48h"})
+	rf.AddFileFixture(t, "bin/tool", &fsfix.FileFixtureArgs{
+		Content:        "#!/bin/sh",
+		Permissions:    0755,
+		DirPermissions: 0750,
+		ModifiedTime:   time.Date(2023, 6, 7, 8, 9, 10, 0, time.UTC),
+	})
+	rf.AddFileFixture(t, "app.log", &fsfix.FileFixtureArgs{
+		Writer: &fsfix.LiveWriterArgs{
+			Interval:    time.Millisecond,
+			Count:       3,
+			RotateEvery: 2,
+			Rotation:    fsfix.CopyTruncateRotation,
+		},
+	})
+	df := tf.AddDirFixture(t, "data", &fsfix.DirFixtureArgs{Permissions: 0700})
+	df.AddFileFixture(t, "queue", &fsfix.FileFixtureArgs{Kind: fsfix.NamedPipeKind})
+	df.AddFileFixture(t, "records.csv", &fsfix.FileFixtureArgs{
+		Permissions:    0600,
+		ModifiedOffset: "-1h",
+		Lock:           &fsfix.FileLockArgs{Kind: fsfix.ReadRangeLock, Start: 4, Len: 8},
+		XAttrs:         map[string][]byte{"user.origin": []byte("test")},
+	})
+	return tf
+}
+
+func TestFixtureJSONRoundTrip(t *testing.T) {
+	tf := declareJSONTree(t)
+	data, err := json.MarshalIndent(tf, "", "  ")
+	if err != nil {
+		t.Fatalf("Marshal(); %v", err)
+	}
+	for _, field := range []string{
+		`"dirPrefix": "json"`,
+		`"type": "repo"`,
+		`"permissions": "0755"`,
+		`"kind": "named pipe"`,
+		`"kind": "read range lock"`,
+		`"rotation": "copy-truncate"`,
+		`"interval": "1ms"`,
+		`"doNotCreate": true`,
+		`"project": "demo"`,
+	} {
+		if !strings.Contains(string(data), field) {
+			t.Errorf("Marshal(): want %s in:\n%s", field, data)
+		}
+	}
+
+	decoded, err := fsfix.NewRootFixtureFromJSON(t, data, nil)
+	if err != nil {
+		t.Fatalf("NewRootFixtureFromJSON(); %v", err)
+	}
+	again, err := json.MarshalIndent(decoded, "", "  ")
+	if err != nil {
+		t.Fatalf("Marshal() of decoded tree; %v", err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("Round trip:\nwant:\n%s\ngot:\n%s", data, again)
+	}
+	if decoded.String() != tf.String() {
+		t.Errorf("Decoded tree:\nwant:\n%s\ngot:\n%s", tf, decoded)
+	}
+	readme, ok := decoded.Lookup("README.md").(*fsfix.FileFixture)
+	if !ok || readme.Vars["project"] != "demo" || readme.Vars["port"] != float64(8080) {
+		t.Errorf("Decoded Vars: want project demo and port 8080, got %+v", readme)
+	}
+}
+
+func TestFixtureJSONZeroPermissions(t *testing.T) {
+	tf := fsfix.NewRootFixture("json-zero-perms")
+	tf.AddFileFixture(t, "secret.txt", nil)
+	tf.AddDirFixture(t, "sealed", nil)
+	tf.SetPermissions(t, "secret.txt", 0)
+	tf.SetPermissions(t, "sealed", 0)
+	data, err := json.Marshal(tf)
+	if err != nil {
+		t.Fatalf("Marshal(); %v", err)
+	}
+
+	decoded, err := fsfix.NewRootFixtureFromJSON(t, data, nil)
+	if err != nil {
+		t.Fatalf("NewRootFixtureFromJSON(); %v", err)
+	}
+	secret, ok := decoded.Lookup("secret.txt").(*fsfix.FileFixture)
+	if !ok || secret.Permissions != 0 {
+		t.Errorf("Decoded secret.txt: want permissions 0000, got %+v", secret)
+	}
+	sealed, ok := decoded.Lookup("sealed").(*fsfix.DirFixture)
+	if !ok || sealed.Permissions != 0 {
+		t.Errorf("Decoded sealed: want permissions 0000, got %+v", sealed)
+	}
+	again, err := json.Marshal(decoded)
+	if err != nil || string(again) != string(data) {
+		t.Errorf("Round trip:\nwant:\n%s\ngot:\n%s (%v)", data, again, err)
+	}
+}
+
+func TestFixtureJSONVarsNotEncodable(t *testing.T) {
+	tf := fsfix.NewRootFixture("json-vars")
+	tf.AddFileFixture(t, "notes.txt", &fsfix.FileFixtureArgs{
+		Vars: map[string]any{"done": make(chan struct{})},
+	})
+	_, err := json.Marshal(tf)
+	if !errors.Is(err, fsfix.ErrVarNotEncoded) {
+		t.Errorf("Marshal() with a channel in Vars: want ErrVarNotEncoded, got %v", err)
+	}
+}
+
+func TestFixtureJSONContentFunc(t *testing.T) {
+	tf := fsfix.NewRootFixture("json-content-func")
+	defer tf.Cleanup()
+	ff := tf.AddFileFixture(t, "generated.txt", &fsfix.FileFixtureArgs{
+		ContentFunc: myContentFunc(7),
+	})
+
+	data, err := json.Marshal(ff)
+	if err != nil || !strings.Contains(string(data), `"contentFunc":true`) {
+		t.Errorf("Marshal() before Create(): want contentFunc flag, got %s (%v)", data, err)
+	}
+	data, err = json.Marshal(tf)
+	if err != nil {
+		t.Fatalf("Marshal(); %v", err)
+	}
+	_, err = fsfix.NewRootFixtureFromJSON(t, data, nil)
+	if !errors.Is(err, fsfix.ErrFuncNotEncoded) {
+		t.Errorf("NewRootFixtureFromJSON() with unevaluated ContentFunc: want ErrFuncNotEncoded, got %v", err)
+	}
+
+	tf.Create(t)
+	data, err = json.Marshal(tf)
+	if err != nil {
+		t.Fatalf("Marshal(); %v", err)
+	}
+	decoded, err := fsfix.NewRootFixtureFromJSON(t, data, nil)
+	if err != nil {
+		t.Fatalf("NewRootFixtureFromJSON() after Create(); %v", err)
+	}
+	got, ok := decoded.Lookup("generated.txt").(*fsfix.FileFixture)
+	if !ok || got.Content != "Text File #7\n" {
+		t.Errorf("Decoded content: want %q, got %+v", "Text File #7\n", got)
+	}
+}
+
+func TestFixtureJSONInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"malformed":     `{"dirPrefix":`,
+		"unknown type":  `{"dirPrefix":"x","children":[{"type":"link","name":"a"}]}`,
+		"unknown kind":  `{"dirPrefix":"x","files":[{"name":"a","kind":"door"}]}`,
+		"bad mode":      `{"dirPrefix":"x","files":[{"name":"a","permissions":"0999"}]}`,
+		"writer's data": `{"dirPrefix":"x","files":[{"name":"a","writer":{"dataFunc":true}}]}`,
+	} {
+		_, err := fsfix.NewRootFixtureFromJSON(t, []byte(data), nil)
+		if err == nil {
+			t.Errorf("NewRootFixtureFromJSON() with %s: want error, got nil", name)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestForkIsIndependent(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b fsfix.Backend) {
+		base := buildTree(t, b)
+		defer base.Cleanup()
+		want := readTree(t, base)
+
+		fork := base.Fork(t)
+		defer fork.Cleanup()
+		if fork.Dir() == base.Dir() {
+			t.Fatalf("Fork: got the base's directory %s", fork.Dir())
+		}
+		assertSameTree(t, want, readTree(t, fork))
+
+		mustDo(t, b.WriteFile(filepath.Join(string(fork.Dir()), "README.md"), []byte("# Forked"), 0644))
+		mustDo(t, b.WriteFile(filepath.Join(string(base.Dir()), "data", "records.csv"), []byte("c,d\n"), 0600))
+		assertFileContent(t, b, filepath.Join(string(base.Dir()), "README.md"), "# Readme")
+		assertFileContent(t, b, filepath.Join(string(fork.Dir()), "data", "records.csv"), "a,b\n")
+
+		orig, ok := base.Lookup("data/records.csv").(*fsfix.FileFixture)
+		forked, forkOK := fork.Lookup("data/records.csv").(*fsfix.FileFixture)
+		switch {
+		case !ok || !forkOK:
+			t.Fatalf("Lookup(data/records.csv): got %T and %T, want file fixtures", base.Lookup("data/records.csv"), fork.Lookup("data/records.csv"))
+		case orig == forked:
+			t.Errorf("Lookup(data/records.csv): fork shares the base's fixture")
+		case string(forked.Filepath) != filepath.Join(string(fork.Dir()), "data", "records.csv"):
+			t.Errorf("Lookup(data/records.csv): got %s, want it in %s", forked.Filepath, fork.Dir())
+		}
+	})
+}
+
+func TestForkKeepsEvaluatedContent(t *testing.T) {
+	var calls int
+
+	tf := fsfix.NewRootFixtureWithArgs("fork", &fsfix.RootFixtureArgs{Backend: fsfix.NewMemoryBackend()})
+	tf.AddFileFixture(t, "generated.txt", &fsfix.FileFixtureArgs{
+		ContentFunc: func(*fsfix.FileFixture) string {
+			calls++
+			return "generated"
+		},
+	})
+	tf.Create(t)
+	defer tf.Cleanup()
+
+	fork := tf.Fork(t)
+	defer fork.Cleanup()
+	if calls != 1 {
+		t.Errorf("ContentFunc: got %d calls, want 1", calls)
+	}
+	assertFileContent(t, fork.Backend(), filepath.Join(string(fork.Dir()), "generated.txt"), "generated")
+}
+
+func TestResetOfForkRestoresDeclaredContent(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b fsfix.Backend) {
+		base := buildTree(t, b)
+		defer base.Cleanup()
+		mustDo(t, b.WriteFile(filepath.Join(string(base.Dir()), "README.md"), []byte("# Changed"), 0644))
+
+		fork := base.Fork(t)
+		defer fork.Cleanup()
+		readme := filepath.Join(string(fork.Dir()), "README.md")
+		assertFileContent(t, b, readme, "# Changed")
+		fork.Reset(t)
+		assertFileContent(t, b, readme, "# Readme")
+	})
+}
+
+func TestForkFailsWhenBaseFileIsMissing(t *testing.T) {
+	base := buildTree(t, fsfix.NewMemoryBackend())
+	defer base.Cleanup()
+	mustDo(t, base.Backend().Remove(filepath.Join(string(base.Dir()), "README.md")))
+
+	tb := &erroringTB{TB: t}
+	fork := base.Fork(tb)
+	defer fork.Cleanup()
+	if !strings.Contains(tb.errors, "file does not exist") {
+		t.Errorf("Fork() of a base missing a file: want a not exist error, got %q", tb.errors)
+	}
+}
+
+// erroringTB wraps a real testing.TB but captures Errorf rather than failing
+// the enclosing test.
+type erroringTB struct {
+	testing.TB
+	errors string
+}
+
+func (e *erroringTB) Helper() {}
+
+func (e *erroringTB) Errorf(format string, args ...any) {
+	e.errors += fmt.Sprintf(format, args...)
+}
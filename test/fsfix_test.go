@@ -3,6 +3,7 @@ package test
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/mikeschinkel/go-dt"
@@ -195,3 +196,16 @@ func fileExists(t *testing.T, path dt.Filepath) bool {
 	info, err := dt.StatFile(path)
 	return !os.IsNotExist(err) && !info.IsDir()
 }
+
+func TestRepoFileRelativePath(t *testing.T) {
+	tf := fsfix.NewRootFixture("repo-paths")
+	rf := tf.AddRepoFixture(t, "repo", nil)
+	ff := rf.AddFileFixture(t, "go.mod", &fsfix.FileFixtureArgs{Content: "module example.com/repo"})
+	tf.Create(t)
+	defer tf.Cleanup()
+
+	want := dt.Filepath(filepath.Join("repo", "go.mod"))
+	if got := ff.RelativePath(); got != want {
+		t.Errorf("FileFixture.RelativePath() in a repo: want '%s', got '%s'", want, got)
+	}
+}
@@ -70,6 +70,12 @@ func FuzzFileFixtureArgs(f *testing.F) {
 	}
 
 	f.Fuzz(func(t *testing.T, name string, permissions int) {
+		// Invalid names are rejected via t.Fatalf at Add time, so only
+		// ensure validation itself doesn't panic for those
+		if fsfix.ValidateFixtureName(name) != nil {
+			return
+		}
+
 		// Create a minimal root fixture for testing
 		rf := fsfix.NewRootFixture("fuzz-test")
 
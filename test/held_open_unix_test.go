@@ -0,0 +1,146 @@
+//go:build unix
+
+package test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestHeldOpenUnlink(t *testing.T) {
+	tf := fsfix.NewRootFixture("held-open")
+	defer tf.Cleanup()
+
+	log := tf.AddFileFixture(t, "app.log", &fsfix.FileFixtureArgs{
+		Content:  "before\n",
+		HoldOpen: &fsfix.HoldOpenArgs{Writable: true},
+	})
+	tf.Create(t)
+
+	log.Unlink(t)
+	_, err := os.Stat(string(log.Filepath))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Unlinked file still exists: %v", err)
+	}
+
+	// The handle still writes to the now nameless file
+	h := log.Handle(t)
+	_, err = h.WriteString("after\n")
+	if err != nil {
+		t.Errorf("Failed to write to unlinked file via handle; %v", err)
+	}
+	info, err := h.Stat()
+	if err != nil {
+		t.Fatalf("Failed to stat handle; %v", err)
+	}
+	if links := info.Sys().(*syscall.Stat_t).Nlink; links != 0 {
+		t.Errorf("Link count of unlinked file: want 0, got %d", links)
+	}
+	if info.Size() != int64(len("before\nafter\n")) {
+		t.Errorf("Size of unlinked file: want %d, got %d", len("before\nafter\n"), info.Size())
+	}
+
+	log.ReleaseHandle(t)
+	_, err = h.Stat()
+	if !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Handle still open after ReleaseHandle(): %v", err)
+	}
+}
+
+func TestHeldOpenReplace(t *testing.T) {
+	tf := fsfix.NewRootFixture("held-replace")
+	defer tf.Cleanup()
+
+	local := tf.AddFileFixture(t, "local.conf", &fsfix.FileFixtureArgs{
+		Content:     "old",
+		Permissions: 0600,
+		HoldOpen:    &fsfix.HoldOpenArgs{},
+	})
+	remote := tf.AddFileFixture(t, "remote.conf", &fsfix.FileFixtureArgs{
+		Content:  "old",
+		HoldOpen: &fsfix.HoldOpenArgs{InHelperProcess: true},
+	})
+	tf.Create(t)
+
+	before := inode(t, string(remote.Filepath))
+	local.Replace(t, "new")
+	remote.Replace(t, "new")
+
+	for _, ff := range []*fsfix.FileFixture{local, remote} {
+		content, err := os.ReadFile(string(ff.Filepath))
+		if err != nil || string(content) != "new" {
+			t.Errorf("Content of replaced %s: want 'new', got '%s' (%v)", ff.Name, content, err)
+		}
+	}
+	assertMode(t, dt.EntryPath(local.Filepath), 0600)
+	if inode(t, string(remote.Filepath)) == before {
+		t.Errorf("Replace() did not rename a new file over %s", remote.Name)
+	}
+
+	// The held handle still reads the original
+	content, err := io.ReadAll(local.Handle(t))
+	if err != nil || string(content) != "old" {
+		t.Errorf("Content via held handle: want 'old', got '%s' (%v)", content, err)
+	}
+
+	msg := expectFatal(t, func(tb testing.TB) {
+		remote.Handle(tb)
+	})
+	if msg != "FileFixture 'remote.conf' is not held open by this process" {
+		t.Errorf("Expected Handle() for a helper-held file to be fatal, got %q", msg)
+	}
+}
+
+func inode(t *testing.T, path string) uint64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat %s; %v", path, err)
+	}
+	return uint64(info.Sys().(*syscall.Stat_t).Ino)
+}
+
+func TestReplaceLeavesSiblingsAlone(t *testing.T) {
+	tf := fsfix.NewRootFixture("held-replace")
+	defer tf.Cleanup()
+
+	ff := tf.AddFileFixture(t, "app.conf", &fsfix.FileFixtureArgs{Content: "old"})
+	sibling := tf.AddFileFixture(t, "app.conf.fsfix-replace", &fsfix.FileFixtureArgs{Content: "unrelated"})
+	tf.Create(t)
+
+	ff.Replace(t, "new")
+
+	content, err := os.ReadFile(string(sibling.Filepath))
+	if err != nil || string(content) != "unrelated" {
+		t.Errorf("Content of %s: want 'unrelated', got '%s' (%v)", sibling.Name, content, err)
+	}
+	entries, err := os.ReadDir(string(tf.Dir()))
+	if err != nil || len(entries) != 2 {
+		t.Errorf("Entries after Replace(): want app.conf and its sibling, got %v (%v)", entries, err)
+	}
+}
+
+func TestResetAfterReplace(t *testing.T) {
+	tf := fsfix.NewRootFixture("held-replace")
+	defer tf.Cleanup()
+
+	ff := tf.AddFileFixture(t, "app.conf", &fsfix.FileFixtureArgs{Content: "old"})
+	tf.Create(t)
+
+	ff.Replace(t, "new")
+	if ff.Content != "old" {
+		t.Errorf("Content after Replace(): want the declared 'old', got '%s'", ff.Content)
+	}
+	tf.Reset(t)
+	content, err := os.ReadFile(string(ff.Filepath))
+	if err != nil || string(content) != "old" {
+		t.Errorf("Content after Reset(): want 'old', got '%s' (%v)", content, err)
+	}
+}
@@ -0,0 +1,200 @@
+package test
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+// recordingTB wraps a real testing.TB but captures Fatalf rather than failing
+// the enclosing test, so misuse that fsfix reports via t.Fatalf can be tested.
+type recordingTB struct {
+	testing.TB
+	fatal string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Fatalf(format string, args ...any) {
+	r.fatal = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// expectFatal runs fn with a recordingTB and returns the message passed to
+// Fatalf, or "" if fn completed without calling Fatalf.
+func expectFatal(t *testing.T, fn func(tb testing.TB)) string {
+	t.Helper()
+	r := &recordingTB{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(r)
+	}()
+	<-done
+	return r.fatal
+}
+
+// walker is a fixture whose declared tree can be walked.
+type walker interface {
+	RelativePath() dt.DirPath
+	Walk(fn func(fsfix.Node) error) error
+}
+
+// declaredFiles returns the slash-separated paths, relative to f, of every
+// file declared beneath f that is to be created, e.g. for fstest.TestFS.
+func declaredFiles(t *testing.T, f walker) (files []string) {
+	t.Helper()
+	err := f.Walk(func(n fsfix.Node) error {
+		ff, ok := n.(*fsfix.FileFixture)
+		if !ok || ff.DoNotCreate {
+			return nil
+		}
+		rel, err := filepath.Rel(string(f.RelativePath()), string(ff.RelativePath()))
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Walk(); %v", err)
+	}
+	return files
+}
+
+// buildTree creates the same fixtures regardless of backend.
+func buildTree(t *testing.T, backend fsfix.Backend) *fsfix.RootFixture {
+	t.Helper()
+	tf := declareTree(t, backend)
+	tf.Create(t)
+	return tf
+}
+
+// forEachBackend runs fn as a subtest with a new instance of each Backend.
+func forEachBackend(t *testing.T, fn func(t *testing.T, b fsfix.Backend)) {
+	t.Helper()
+	backends := []struct {
+		name string
+		new  func() fsfix.Backend
+	}{
+		{"os", func() fsfix.Backend { return fsfix.OSBackend{} }},
+		{"memory", func() fsfix.Backend { return fsfix.NewMemoryBackend() }},
+	}
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			fn(t, backend.new())
+		})
+	}
+}
+
+// declareTree declares the fixtures buildTree creates.
+func declareTree(t *testing.T, backend fsfix.Backend) *fsfix.RootFixture {
+	t.Helper()
+	tf := fsfix.NewRootFixtureWithArgs("backend", &fsfix.RootFixtureArgs{
+		Backend:  backend,
+		BaseTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	tf.AddFileFixture(t, "README.md", &fsfix.FileFixtureArgs{Content: "# Readme"})
+	rf := tf.AddRepoFixture(t, "repo", nil)
+	rf.AddFileFixture(t, "bin/tool", &fsfix.FileFixtureArgs{
+		Content:        "#!/bin/sh",
+		Permissions:    0755,
+		DirPermissions: 0750,
+		ModifiedOffset: "-24h",
+	})
+	df := tf.AddDirFixture(t, "data", &fsfix.DirFixtureArgs{
+		Permissions:    0700,
+		ModifiedOffset: "-48h",
+	})
+	df.AddFileFixture(t, "records.csv", &fsfix.FileFixtureArgs{
+		Content:     "a,b\n",
+		Permissions: 0600,
+	})
+	return tf
+}
+
+// treeEntry is what assertions see of one entry in a fixture tree.
+type treeEntry struct {
+	mode    fs.FileMode
+	mtime   time.Time
+	content string
+}
+
+// readTree walks the fixture tree via its Backend.
+func readTree(t *testing.T, tf *fsfix.RootFixture) map[string]treeEntry {
+	t.Helper()
+	b := tf.Backend()
+	tree := make(map[string]treeEntry)
+	var walk func(dir string)
+	walk = func(dir string) {
+		entries, err := b.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("ReadDir(%s); %v", dir, err)
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			info, err := b.Lstat(path)
+			if err != nil {
+				t.Fatalf("Lstat(%s); %v", path, err)
+			}
+			rel := strings.TrimPrefix(path, string(tf.Dir()))
+			te := treeEntry{mode: info.Mode(), mtime: info.ModTime()}
+			if info.IsDir() {
+				walk(path)
+			} else {
+				content, err := b.ReadFile(path)
+				if err != nil {
+					t.Fatalf("ReadFile(%s); %v", path, err)
+				}
+				te.content = string(content)
+			}
+			tree[filepath.ToSlash(rel)] = te
+		}
+	}
+	walk(string(tf.Dir()))
+	return tree
+}
+
+// mustDo fails the test if err is not nil.
+func mustDo(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// assertSameTree reports every entry of got that differs from want.
+func assertSameTree(t *testing.T, want, got map[string]treeEntry) {
+	t.Helper()
+	for rel, w := range want {
+		g, ok := got[rel]
+		switch {
+		case !ok:
+			t.Errorf("%s: missing", rel)
+		case g.mode != w.mode:
+			t.Errorf("%s: got mode %v, want %v", rel, g.mode, w.mode)
+		case !g.mtime.Equal(w.mtime):
+			t.Errorf("%s: got mtime %v, want %v", rel, g.mtime, w.mtime)
+		case g.content != w.content:
+			t.Errorf("%s: got content %q, want %q", rel, g.content, w.content)
+		}
+	}
+	for rel := range got {
+		if _, ok := want[rel]; !ok {
+			t.Errorf("%s: not declared, want removed", rel)
+		}
+	}
+}
+
+// assertFileContent fails t if the file at path in b does not hold want.
+func assertFileContent(t *testing.T, b fsfix.Backend, path, want string) {
+	t.Helper()
+	content, err := b.ReadFile(path)
+	if err != nil || string(content) != want {
+		t.Errorf("Reading %s: want %q, got %q (%v)", path, want, content, err)
+	}
+}
@@ -0,0 +1,149 @@
+package test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestLiveWriterAppends(t *testing.T) {
+	tf := fsfix.NewRootFixture("live-writer")
+	defer tf.Cleanup()
+
+	ff := tf.AddFileFixture(t, "app.log", &fsfix.FileFixtureArgs{
+		Content: "start\n",
+		Writer: &fsfix.LiveWriterArgs{
+			Interval: time.Millisecond,
+			Count:    5,
+		},
+	})
+	tf.Create(t)
+
+	lw := ff.LiveWriter(t)
+	err := lw.Wait()
+	if err != nil {
+		t.Fatalf("LiveWriter failed; %v", err)
+	}
+	if lw.Writes() != 5 {
+		t.Errorf("Writes(): want 5, got %d", lw.Writes())
+	}
+	content, err := os.ReadFile(string(ff.Filepath))
+	if err != nil {
+		t.Fatalf("Failed to read %s; %v", ff.Filepath, err)
+	}
+	want := "start\nline 1\nline 2\nline 3\nline 4\nline 5\n"
+	if string(content) != want {
+		t.Errorf("Content: want %q, got %q", want, content)
+	}
+}
+
+func TestLiveWriterRotation(t *testing.T) {
+	tests := []struct {
+		name     string
+		rotation fsfix.RotationStyle
+	}{
+		{name: "rename", rotation: fsfix.RenameRotation},
+		{name: "copytruncate", rotation: fsfix.CopyTruncateRotation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf := fsfix.NewRootFixture("live-rotate")
+			defer tf.Cleanup()
+
+			ff := tf.AddFileFixture(t, "app.log", &fsfix.FileFixtureArgs{
+				Writer: &fsfix.LiveWriterArgs{
+					Interval:    time.Millisecond,
+					Count:       7,
+					RotateEvery: 3,
+					Rotation:    tt.rotation,
+				},
+			})
+			tf.Create(t)
+
+			lw := ff.LiveWriter(t)
+			err := lw.Wait()
+			if err != nil {
+				t.Fatalf("LiveWriter failed; %v", err)
+			}
+			rotations := lw.Rotations()
+			if len(rotations) != 2 {
+				t.Fatalf("Rotations(): want 2, got %v", rotations)
+			}
+			wants := map[string]string{
+				string(rotations[0]): "line 1\nline 2\nline 3\n",
+				string(rotations[1]): "line 4\nline 5\nline 6\n",
+				string(ff.Filepath):  "line 7\n",
+			}
+			for path, want := range wants {
+				content, err := os.ReadFile(path)
+				if err != nil || string(content) != want {
+					t.Errorf("Content of %s: want %q, got %q (%v)", path, want, content, err)
+				}
+			}
+			if !strings.HasSuffix(string(rotations[1]), "app.log.2") {
+				t.Errorf("Second rotation: want app.log.2, got %s", rotations[1])
+			}
+		})
+	}
+}
+
+func TestLiveWriterTruncateAndStop(t *testing.T) {
+	tf := fsfix.NewRootFixture("live-truncate")
+	defer tf.Cleanup()
+
+	ff := tf.AddFileFixture(t, "app.log", &fsfix.FileFixtureArgs{
+		Writer: &fsfix.LiveWriterArgs{
+			Interval:      time.Millisecond,
+			TruncateEvery: 2,
+			Data: func(n int) []byte {
+				return []byte{byte('a' + n%26)}
+			},
+		},
+	})
+	tf.Create(t)
+
+	lw := ff.LiveWriter(t)
+	deadline := time.Now().Add(5 * time.Second)
+	for lw.Writes() < 4 {
+		if time.Now().After(deadline) {
+			t.Fatalf("LiveWriter made only %d writes", lw.Writes())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	err := lw.Stop()
+	if err != nil {
+		t.Fatalf("LiveWriter failed; %v", err)
+	}
+	writes := lw.Writes()
+	content, err := os.ReadFile(string(ff.Filepath))
+	if err != nil {
+		t.Fatalf("Failed to read %s; %v", ff.Filepath, err)
+	}
+	// Truncated after every even write, so at most one byte survives
+	if len(content) != writes%2 {
+		t.Errorf("Content after %d writes: want %d bytes, got %q", writes, writes%2, content)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if lw.Writes() != writes {
+		t.Errorf("LiveWriter kept writing after Stop()")
+	}
+}
+
+func TestLiveWriterStoppedByCleanup(t *testing.T) {
+	tf := fsfix.NewRootFixture("live-cleanup")
+	ff := tf.AddFileFixture(t, "app.log", &fsfix.FileFixtureArgs{
+		Writer: &fsfix.LiveWriterArgs{Interval: time.Millisecond},
+	})
+	tf.Create(t)
+	tf.Cleanup()
+
+	lw := ff.LiveWriter(t)
+	writes := lw.Writes()
+	time.Sleep(5 * time.Millisecond)
+	if lw.Writes() != writes {
+		t.Errorf("LiveWriter kept writing after Cleanup()")
+	}
+}
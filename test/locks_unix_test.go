@@ -0,0 +1,152 @@
+//go:build unix
+
+package test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestFlockFixtures(t *testing.T) {
+	tf := fsfix.NewRootFixture("flocks")
+	defer tf.Cleanup()
+
+	exclusive := tf.AddFileFixture(t, "exclusive.lock", &fsfix.FileFixtureArgs{
+		Lock: &fsfix.FileLockArgs{Kind: fsfix.ExclusiveFlock},
+	})
+	shared := tf.AddFileFixture(t, "shared.lock", &fsfix.FileFixtureArgs{
+		Lock: &fsfix.FileLockArgs{
+			Kind:            fsfix.SharedFlock,
+			InHelperProcess: true,
+		},
+	})
+	tf.Create(t)
+
+	if tryFlock(t, string(exclusive.Filepath), syscall.LOCK_SH) {
+		t.Errorf("Shared flock acquired while fixture holds an exclusive one")
+	}
+	if !tryFlock(t, string(shared.Filepath), syscall.LOCK_SH) {
+		t.Errorf("Shared flock not acquired alongside the helper's shared one")
+	}
+	if tryFlock(t, string(shared.Filepath), syscall.LOCK_EX) {
+		t.Errorf("Exclusive flock acquired while helper holds a shared one")
+	}
+
+	exclusive.ReleaseLock(t)
+	shared.ReleaseLock(t)
+	if !tryFlock(t, string(exclusive.Filepath), syscall.LOCK_EX) {
+		t.Errorf("Exclusive flock not acquired after ReleaseLock()")
+	}
+	if !tryFlock(t, string(shared.Filepath), syscall.LOCK_EX) {
+		t.Errorf("Exclusive flock not acquired after helper's ReleaseLock()")
+	}
+}
+
+func TestRangeLockFixture(t *testing.T) {
+	tf := fsfix.NewRootFixture("range-lock")
+	defer tf.Cleanup()
+
+	db := tf.AddFileFixture(t, "data.db", &fsfix.FileFixtureArgs{
+		Content: "0123456789abcdefghij",
+		Lock: &fsfix.FileLockArgs{
+			Kind:  fsfix.WriteRangeLock,
+			Start: 0,
+			Len:   10,
+		},
+	})
+	tf.Create(t)
+
+	if tryRangeLock(t, string(db.Filepath), 5, 5) {
+		t.Errorf("Range lock acquired on bytes held by the helper process")
+	}
+	if !tryRangeLock(t, string(db.Filepath), 10, 10) {
+		t.Errorf("Range lock not acquired on bytes outside the held range")
+	}
+
+	// Retry as code under test would until the lock is released
+	db.ReleaseLockAfter(t, 50*time.Millisecond)
+	deadline := time.Now().Add(5 * time.Second)
+	for !tryRangeLock(t, string(db.Filepath), 5, 5) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Range lock still held after ReleaseLockAfter()")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReleaseLockWithoutLock(t *testing.T) {
+	tf := fsfix.NewRootFixture("no-lock")
+	defer tf.Cleanup()
+	ff := tf.AddFileFixture(t, "plain.txt", nil)
+	tf.Create(t)
+
+	msg := expectFatal(t, func(tb testing.TB) {
+		ff.ReleaseLock(tb)
+	})
+	if msg != "FileFixture 'plain.txt' does not hold a lock" {
+		t.Errorf("Expected ReleaseLock() without a lock to be fatal, got %q", msg)
+	}
+}
+
+func TestHelperProcessRejectsInvalidSpec(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(exe, "-fsfix.helper", "lock oops")
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Fatalf("Helper with an invalid spec: want exit status 1, got %v", err)
+	}
+	if !strings.Contains(string(out), fsfix.ErrInvalidHelperSpec.Error()) {
+		t.Errorf("Helper output: want %q, got %q", fsfix.ErrInvalidHelperSpec, out)
+	}
+}
+
+// tryFlock reports whether a flock of kind can be taken on path right now,
+// releasing it again if so.
+func tryFlock(t *testing.T, path string, how int) bool {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open %s; %v", path, err)
+	}
+	defer func() { _ = f.Close() }()
+	err = syscall.Flock(int(f.Fd()), how|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false
+	}
+	if err != nil {
+		t.Fatalf("Failed to flock %s; %v", path, err)
+	}
+	return true
+}
+
+// tryRangeLock reports whether a write lock can be taken on a byte range of
+// path right now, releasing it again if so.
+func tryRangeLock(t *testing.T, path string, start, n int64) bool {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("Failed to open %s; %v", path, err)
+	}
+	// Closing releases this process's locks on the file
+	defer func() { _ = f.Close() }()
+	lk := syscall.Flock_t{Type: syscall.F_WRLCK, Start: start, Len: n}
+	err = syscall.FcntlFlock(f.Fd(), syscall.F_SETLK, &lk)
+	if errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EACCES) {
+		return false
+	}
+	if err != nil {
+		t.Fatalf("Failed to lock range of %s; %v", path, err)
+	}
+	return true
+}
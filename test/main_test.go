@@ -0,0 +1,15 @@
+package test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+// TestMain lets this test binary serve as the helper process that holds
+// locks and open files for fixtures using InHelperProcess or range locks.
+func TestMain(m *testing.M) {
+	fsfix.HelperMain()
+	os.Exit(m.Run())
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestRootFixtureFromMapFS(t *testing.T) {
+	modTime := time.Date(2023, 6, 7, 8, 9, 10, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"README.md":      {Data: []byte("# Readme")},
+		"bin":            {Mode: fs.ModeDir | 0750, ModTime: modTime},
+		"bin/tool":       {Data: []byte("#!/bin/sh"), Mode: 0755, ModTime: modTime},
+		"etc/app.conf":   {Data: []byte("debug=1"), Mode: 0600},
+		"var/log":        {Mode: fs.ModeDir},
+		"var/log/.empty": {},
+	}
+	forEachBackend(t, func(t *testing.T, backend fsfix.Backend) {
+		tf := fsfix.NewRootFixtureFromMapFS(t, "from-map-fs", fsys, &fsfix.RootFixtureArgs{
+			Backend: backend,
+		})
+		defer tf.Cleanup()
+		tf.Create(t)
+		err := fstest.TestFS(tf.FS(), declaredFiles(t, tf)...)
+		if err != nil {
+			t.Errorf("FS() of fixture from MapFS; %v", err)
+		}
+		tree := readTree(t, tf)
+		for path, want := range map[string]treeEntry{
+			"/README.md":    {mode: 0644, content: "# Readme"},
+			"/bin":          {mode: fs.ModeDir | 0750, mtime: modTime},
+			"/bin/tool":     {mode: 0755, mtime: modTime, content: "#!/bin/sh"},
+			"/etc/app.conf": {mode: 0600, content: "debug=1"},
+			"/var/log":      {mode: fs.ModeDir | 0755},
+		} {
+			got, ok := tree[path]
+			switch {
+			case !ok:
+				t.Errorf("%s: missing", path)
+			case got.mode != want.mode:
+				t.Errorf("%s: mode want %s, got %s", path, want.mode, got.mode)
+			case !want.mtime.IsZero() && !got.mtime.Equal(want.mtime):
+				t.Errorf("%s: mtime want %s, got %s", path, want.mtime, got.mtime)
+			case got.content != want.content:
+				t.Errorf("%s: content want %q, got %q", path, want.content, got.content)
+			}
+		}
+	})
+}
+
+func TestRootFixtureFromMapFSRejectsSymlinks(t *testing.T) {
+	msg := expectFatal(t, func(tb testing.TB) {
+		fsfix.NewRootFixtureFromMapFS(tb, "from-map-fs", fstest.MapFS{
+			"current": {Data: []byte("app.log"), Mode: fs.ModeSymlink},
+		}, nil)
+	})
+	if msg == "" {
+		t.Errorf("NewRootFixtureFromMapFS() with symlink: want fatal, got none")
+	}
+}
+
+func TestRootFixtureMapFS(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend fsfix.Backend) {
+		tf := declareTree(t, backend)
+		defer tf.Cleanup()
+		fsys := tf.MapFS()
+		err := fstest.TestFS(fsys, declaredFiles(t, tf)...)
+		if err != nil {
+			t.Errorf("MapFS(); %v", err)
+		}
+
+		tf.Create(t)
+		tree := readTree(t, tf)
+		if len(fsys) != len(tree) {
+			t.Errorf("Entries: want %d, got %d", len(tree), len(fsys))
+		}
+		for path, want := range tree {
+			got, ok := fsys[path[1:]]
+			switch {
+			case !ok:
+				t.Errorf("%s: missing from MapFS()", path)
+			case got.Mode != want.mode:
+				t.Errorf("%s: mode want %s, got %s", path, want.mode, got.Mode)
+			case !got.ModTime.Equal(want.mtime):
+				t.Errorf("%s: mtime want %s, got %s", path, want.mtime, got.ModTime)
+			case string(got.Data) != want.content:
+				t.Errorf("%s: content want %q, got %q", path, want.content, got.Data)
+			}
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestMemoryBackendMatchesOS(t *testing.T) {
+	onDisk := buildTree(t, nil)
+	defer onDisk.Cleanup()
+	inMemory := buildTree(t, fsfix.NewMemoryBackend())
+	defer inMemory.Cleanup()
+
+	want := readTree(t, onDisk)
+	got := readTree(t, inMemory)
+	if len(got) != len(want) {
+		t.Errorf("Entries: want %d, got %d", len(want), len(got))
+	}
+	for path, w := range want {
+		g, ok := got[path]
+		switch {
+		case !ok:
+			t.Errorf("%s: missing from memory backend", path)
+		case g.mode != w.mode:
+			t.Errorf("%s: mode want %s, got %s", path, w.mode, g.mode)
+		case !g.mtime.Equal(w.mtime):
+			t.Errorf("%s: mtime want %s, got %s", path, w.mtime, g.mtime)
+		case g.content != w.content:
+			t.Errorf("%s: content want %q, got %q", path, w.content, g.content)
+		}
+	}
+
+	_, err := os.Stat(string(inMemory.Dir()))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Memory backend fixture exists on disk: %s", inMemory.Dir())
+	}
+	err = fstest.TestFS(inMemory.FS(), declaredFiles(t, inMemory)...)
+	if err != nil {
+		t.Errorf("FS() with memory backend; %v", err)
+	}
+}
+
+func TestMemoryBackendReadWrite(t *testing.T) {
+	b := fsfix.NewMemoryBackend()
+	tf := fsfix.NewRootFixtureWithArgs("memory", &fsfix.RootFixtureArgs{Backend: b})
+	defer tf.Cleanup()
+	ff := tf.AddFileFixture(t, "logs/app.log", &fsfix.FileFixtureArgs{Content: "one\n"})
+	tf.Create(t)
+
+	// Code under test appends through the Backend
+	f, err := b.OpenFile(string(ff.Filepath), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("OpenFile(); %v", err)
+	}
+	_, err = f.Write([]byte("two\n"))
+	if err != nil {
+		t.Fatalf("Write(); %v", err)
+	}
+	_ = f.Close()
+	content, err := fs.ReadFile(tf.FS(), "logs/app.log")
+	if err != nil || string(content) != "one\ntwo\n" {
+		t.Errorf("ReadFile(): want %q, got %q (%v)", "one\ntwo\n", content, err)
+	}
+
+	// Symlinks, relative and absolute, resolve as on disk
+	logs := string(ff.Filepath.Dir())
+	current := filepath.Join(logs, "current")
+	err = b.Symlink("app.log", current)
+	if err != nil {
+		t.Fatalf("Symlink(); %v", err)
+	}
+	err = b.Symlink(logs, filepath.Join(string(tf.Dir()), "latest"))
+	if err != nil {
+		t.Fatalf("Symlink(); %v", err)
+	}
+	target, err := b.Readlink(current)
+	if err != nil || target != "app.log" {
+		t.Errorf("Readlink(): want 'app.log', got '%s' (%v)", target, err)
+	}
+	info, err := b.Lstat(current)
+	if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		t.Errorf("Lstat(): want symlink, got %v (%v)", info, err)
+	}
+	content, err = b.ReadFile(filepath.Join(string(tf.Dir()), "latest", "current"))
+	if err != nil || string(content) != "one\ntwo\n" {
+		t.Errorf("ReadFile() via symlinks: want %q, got %q (%v)", "one\ntwo\n", content, err)
+	}
+
+	// Renames and removals follow os semantics
+	err = b.Remove(logs)
+	if err == nil {
+		t.Errorf("Remove() of non-empty directory: want error, got nil")
+	}
+	rotated := filepath.Join(logs, "app.log.1")
+	err = b.Rename(string(ff.Filepath), rotated)
+	if err != nil {
+		t.Fatalf("Rename(); %v", err)
+	}
+	_, err = b.Stat(current)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat() of dangling symlink: want not exist, got %v", err)
+	}
+	err = b.Chmod(rotated, 0400)
+	if err != nil {
+		t.Fatalf("Chmod(); %v", err)
+	}
+	assertBackendMode(t, b, dt.EntryPath(rotated), 0400)
+	_, err = b.OpenFile(rotated, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if !errors.Is(err, fs.ErrExist) {
+		t.Errorf("OpenFile() with O_EXCL on existing file: want exist, got %v", err)
+	}
+}
+
+func TestMemoryBackendConcurrentSeek(t *testing.T) {
+	b := fsfix.NewMemoryBackend()
+	tf := fsfix.NewRootFixtureWithArgs("memory", &fsfix.RootFixtureArgs{Backend: b})
+	defer tf.Cleanup()
+	ff := tf.AddFileFixture(t, "data.bin", &fsfix.FileFixtureArgs{Content: "0123456789"})
+	tf.Create(t)
+	f, err := b.OpenFile(string(ff.Filepath), os.O_RDONLY, 0)
+	if err != nil {
+		t.Fatalf("OpenFile(); %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	// Run with -race: every Seek() moves the offset shared by the goroutines
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for range 1000 {
+				_, _ = f.Seek(0, io.SeekEnd)
+				_, _ = f.Seek(-2, io.SeekCurrent)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestMemoryBackendRejectsOSOnlyFeatures(t *testing.T) {
+	tf := fsfix.NewRootFixtureWithArgs("memory", &fsfix.RootFixtureArgs{
+		Backend: fsfix.NewMemoryBackend(),
+	})
+	msg := expectFatal(t, func(tb testing.TB) {
+		tf.AddFileFixture(tb, "app.lock", &fsfix.FileFixtureArgs{
+			Lock: &fsfix.FileLockArgs{Kind: fsfix.ExclusiveFlock},
+		})
+	})
+	if !strings.Contains(msg, "uses Lock, which needs the OS backend") {
+		t.Errorf("Expected Lock with memory backend to be fatal, got %q", msg)
+	}
+}
+
+func TestMemoryBackendLiveWriter(t *testing.T) {
+	tf := fsfix.NewRootFixtureWithArgs("memory", &fsfix.RootFixtureArgs{
+		Backend: fsfix.NewMemoryBackend(),
+	})
+	defer tf.Cleanup()
+	ff := tf.AddFileFixture(t, "app.log", &fsfix.FileFixtureArgs{
+		Writer: &fsfix.LiveWriterArgs{
+			Interval:    time.Millisecond,
+			Count:       4,
+			RotateEvery: 2,
+			Rotation:    fsfix.CopyTruncateRotation,
+		},
+	})
+	tf.Create(t)
+
+	err := ff.LiveWriter(t).Wait()
+	if err != nil {
+		t.Fatalf("LiveWriter failed; %v", err)
+	}
+	content, err := tf.Backend().ReadFile(string(ff.Filepath) + ".1")
+	if err != nil || string(content) != "line 1\nline 2\n" {
+		t.Errorf("Rotated content: want %q, got %q (%v)", "line 1\nline 2\n", content, err)
+	}
+}
+
+func assertBackendMode(t *testing.T, b fsfix.Backend, ep dt.EntryPath, want fs.FileMode) {
+	t.Helper()
+	info, err := b.Lstat(string(ep))
+	if err != nil {
+		t.Fatalf("Failed to stat %s; %v", ep, err)
+	}
+	if info.Mode() != want {
+		t.Errorf("Mode of %s: want %s, got %s", ep, want, info.Mode())
+	}
+}
@@ -0,0 +1,64 @@
+//go:build unix
+
+package test
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestCanChown(t *testing.T) {
+	self := strconv.Itoa(os.Geteuid())
+	err := fsfix.CanChown(self, strconv.Itoa(os.Getegid()))
+	if err != nil {
+		t.Errorf("CanChown() for current user and group: want nil, got %v", err)
+	}
+
+	err = fsfix.CanChown("no-such-user-for-fsfix", "")
+	if !errors.Is(err, fsfix.ErrUnknownOwner) {
+		t.Errorf("CanChown() for unknown user: want ErrUnknownOwner, got %v", err)
+	}
+
+	err = fsfix.CanChown("12345", "")
+	if os.Geteuid() != 0 && !errors.Is(err, fsfix.ErrInsufficientPrivilege) {
+		t.Errorf("CanChown() as non-root: want ErrInsufficientPrivilege, got %v", err)
+	}
+}
+
+func TestOwnershipFixtures(t *testing.T) {
+	tf := fsfix.NewRootFixture("ownership")
+	defer tf.Cleanup()
+
+	// Skips the test unless running as root
+	ff := tf.AddFileFixture(t, "owned.txt", &fsfix.FileFixtureArgs{
+		Owner:       "12345",
+		Group:       "23456",
+		Permissions: 04755,
+	})
+	df := tf.AddDirFixture(t, "owned", &fsfix.DirFixtureArgs{
+		Owner: "12345",
+	})
+	tf.Create(t)
+
+	assertOwner(t, dt.EntryPath(ff.Filepath), 12345, 23456)
+	assertOwner(t, dt.EntryPath(df.Dir()), 12345, os.Getegid())
+	assertMode(t, dt.EntryPath(ff.Filepath), 0755|os.ModeSetuid)
+}
+
+func assertOwner(t *testing.T, ep dt.EntryPath, uid, gid int) {
+	t.Helper()
+	info, err := ep.Lstat()
+	if err != nil {
+		t.Fatalf("Failed to stat %s; %v", ep, err)
+	}
+	st := info.Sys().(*syscall.Stat_t)
+	if int(st.Uid) != uid || int(st.Gid) != gid {
+		t.Errorf("Ownership of %s: want %d:%d, got %d:%d", ep, uid, gid, st.Uid, st.Gid)
+	}
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestDuplicateFileFixture(t *testing.T) {
+	tf := fsfix.NewRootFixture("dup-test")
+	tf.AddFileFixture(t, "a.txt", nil)
+
+	msg := expectFatal(t, func(tb testing.TB) {
+		tf.AddFileFixture(tb, "a.txt", nil)
+	})
+	if !strings.Contains(msg, "duplicates file 'a.txt'") {
+		t.Errorf("Duplicate FileFixture not reported; got '%s'", msg)
+	}
+	if strings.Count(msg, "path_index_test.go:") != 2 {
+		t.Errorf("Both call sites not reported; got '%s'", msg)
+	}
+}
+
+func TestConflictingFileAndDirFixture(t *testing.T) {
+	tf := fsfix.NewRootFixture("conflict-test")
+	tf.AddFileFixture(t, "a/b", nil)
+
+	msg := expectFatal(t, func(tb testing.TB) {
+		df := tf.AddDirFixture(tb, "a", nil)
+		df.AddDirFixture(tb, "b", nil)
+	})
+	if !strings.Contains(msg, "conflicts with file 'a/b'") {
+		t.Errorf("File/dir conflict not reported; got '%s'", msg)
+	}
+
+	tf = fsfix.NewRootFixture("conflict-test")
+	tf.AddFileFixture(t, "a/b", nil)
+	msg = expectFatal(t, func(tb testing.TB) {
+		tf.AddFileFixture(tb, "a", nil)
+	})
+	if !strings.Contains(msg, "conflicts with implied directory 'a'") {
+		t.Errorf("File/implied dir conflict not reported; got '%s'", msg)
+	}
+}
+
+func TestOverrideFixture(t *testing.T) {
+	tf := fsfix.NewRootFixture("override-test")
+	defer tf.Cleanup()
+
+	tf.AddFileFixture(t, "a.txt", &fsfix.FileFixtureArgs{Content: "old"})
+	ff := tf.AddFileFixture(t, "a.txt", &fsfix.FileFixtureArgs{
+		Content:  "new",
+		Override: true,
+	})
+	if len(tf.FileFixtures) != 1 || tf.FileFixtures[0] != ff {
+		t.Fatalf("Override did not replace the earlier FileFixture; got %d fixtures", len(tf.FileFixtures))
+	}
+
+	tf.Create(t)
+	if !fileExists(t, ff.Filepath) {
+		t.Errorf("FileFixture.Filepath doesn't exist: %s", ff.Filepath)
+	}
+}
+
+func TestDuplicateDirFixtureArgsFiles(t *testing.T) {
+	tf := fsfix.NewRootFixture("dup-test")
+
+	msg := expectFatal(t, func(tb testing.TB) {
+		tf.AddDirFixture(tb, "a", &fsfix.DirFixtureArgs{
+			Files: []*fsfix.FileFixture{{Name: "dup.txt"}, {Name: "dup.txt"}},
+		})
+	})
+	if !strings.Contains(msg, "duplicates file 'a/dup.txt'") {
+		t.Errorf("Duplicate file in DirFixtureArgs.Files not reported; got '%s'", msg)
+	}
+	if strings.Count(msg, "path_index_test.go:") != 2 {
+		t.Errorf("Both call sites not reported; got '%s'", msg)
+	}
+
+	tf = fsfix.NewRootFixture("index-test")
+	tf.AddDirFixture(t, "a", &fsfix.DirFixtureArgs{
+		Files: []*fsfix.FileFixture{{Name: "listed.txt"}},
+	})
+	tf.Remove(t, "a/listed.txt")
+	if tf.Lookup("a/listed.txt") != nil {
+		t.Errorf("Remove(a/listed.txt): file from DirFixtureArgs.Files still declared")
+	}
+}
@@ -0,0 +1,104 @@
+//go:build unix
+
+package test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestExactPermissionsIgnoreUmask(t *testing.T) {
+	old := syscall.Umask(022)
+	defer syscall.Umask(old)
+
+	tf := fsfix.NewRootFixture("exact-perms")
+	defer tf.Cleanup()
+	shared := tf.AddFileFixture(t, "shared.txt", &fsfix.FileFixtureArgs{
+		Permissions: 0666,
+	})
+	tool := tf.AddFileFixture(t, "bin/tool", &fsfix.FileFixtureArgs{
+		Permissions:    04755,
+		DirPermissions: 0775,
+	})
+	drop := tf.AddDirFixture(t, "drop", &fsfix.DirFixtureArgs{
+		Permissions: 03777,
+	})
+	locked := tf.AddDirFixture(t, "locked", &fsfix.DirFixtureArgs{
+		Permissions: 0500,
+	})
+	// Children are still created even though their parent is read-only
+	inner := locked.AddFileFixture(t, "inner.txt", nil)
+	tf.Create(t)
+
+	assertMode(t, dt.EntryPath(shared.Filepath), 0666)
+	assertMode(t, dt.EntryPath(tool.Filepath), 0755|os.ModeSetuid)
+	assertMode(t, dt.EntryPath(tool.Filepath.Dir()), os.ModeDir|0775)
+	assertMode(t, dt.EntryPath(drop.Dir()), os.ModeDir|os.ModeSetgid|os.ModeSticky|0777)
+	assertMode(t, dt.EntryPath(locked.Dir()), os.ModeDir|0500)
+	if !fileExists(t, inner.Filepath) {
+		t.Errorf("FileFixture.Filepath doesn't exist: %s", inner.Filepath)
+	}
+}
+
+func TestExplicitZeroPermissions(t *testing.T) {
+	tf := fsfix.NewRootFixture("zero-perms")
+	defer tf.Cleanup()
+	secret := tf.AddFileFixture(t, "secret.txt", nil)
+	sealed := tf.AddDirFixture(t, "sealed", nil)
+	tf.SetPermissions(t, "secret.txt", 0)
+	tf.SetPermissions(t, "sealed", 0)
+	tf.Create(t)
+
+	assertMode(t, dt.EntryPath(secret.Filepath), 0)
+	assertMode(t, dt.EntryPath(sealed.Dir()), os.ModeDir)
+}
+
+func TestArgsFilesGetDefaultPermissions(t *testing.T) {
+	tf := fsfix.NewRootFixture("args-files-perms")
+	defer tf.Cleanup()
+	a := &fsfix.FileFixture{Name: "sub/a.txt", Content: "x"}
+	b := &fsfix.FileFixture{Name: "b.txt", Content: "y"}
+	df := tf.AddDirFixture(t, "dir", &fsfix.DirFixtureArgs{Files: []*fsfix.FileFixture{a}})
+	tf.AddRepoFixture(t, "repo", &fsfix.RepoFixtureArgs{Files: []*fsfix.FileFixture{b}})
+	tf.Create(t)
+
+	assertMode(t, dt.EntryPath(a.Filepath), 0644)
+	assertMode(t, dt.EntryPath(dt.DirPathJoin(df.Dir(), "sub")), os.ModeDir|0755)
+	assertMode(t, dt.EntryPath(b.Filepath), 0644)
+}
+
+func TestEscapedNameLeavesBaseDirMode(t *testing.T) {
+	base := t.TempDir()
+	err := os.Chmod(base, os.ModeSticky|0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tf := fsfix.NewRootFixtureWithArgs("escape-perms", &fsfix.RootFixtureArgs{
+		BaseDir: dt.DirPath(base),
+	})
+	defer tf.Cleanup()
+	for _, name := range []dt.RelFilepath{"../escaped.txt", "../escaped/x.txt", "../../far.txt"} {
+		tf.AddFileFixture(t, name, &fsfix.FileFixtureArgs{
+			DirPermissions: 0700,
+			AllowEscape:    true,
+		})
+	}
+	tf.Create(t)
+
+	assertMode(t, dt.EntryPath(base), os.ModeDir|os.ModeSticky|0777)
+}
+
+func assertMode(t *testing.T, ep dt.EntryPath, want os.FileMode) {
+	t.Helper()
+	info, err := ep.Lstat()
+	if err != nil {
+		t.Fatalf("Failed to stat %s; %v", ep, err)
+	}
+	if info.Mode() != want {
+		t.Errorf("Mode of %s: want %s, got %s", ep, want, info.Mode())
+	}
+}
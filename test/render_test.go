@@ -0,0 +1,124 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestRenderTree(t *testing.T) {
+	tf := fsfix.NewRootFixtureWithArgs("render", &fsfix.RootFixtureArgs{
+		BaseTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	defer tf.Cleanup()
+
+	tf.AddFileFixture(t, "README.md", &fsfix.FileFixtureArgs{Content: "# Readme"})
+	rf := tf.AddRepoFixture(t, "repo", nil)
+	rf.AddFileFixture(t, "cmd/tool/main.go", &fsfix.FileFixtureArgs{
+		Content:        "package main",
+		Permissions:    0600,
+		ModifiedOffset: "-24h",
+	})
+	tf.AddFileFixture(t, "missing.txt", &fsfix.FileFixtureArgs{DoNotCreate: true})
+
+	want := `RootFixture 'render'
+├── README.md            -rw-r--r-- 8B 2024-01-02T03:04:05Z
+├── missing.txt          DoNotCreate
+└── repo/ [repo]         drwxr-xr-x 2024-01-02T03:04:05Z
+    ├── .git/            drwxr-xr-x 2024-01-02T03:04:05Z
+    └── cmd/             drwxr-xr-x 2024-01-02T03:04:05Z
+        └── tool/        drwxr-xr-x 2024-01-02T03:04:05Z
+            └── main.go  -rw------- 12B 2024-01-01T03:04:05Z
+`
+	if got := tf.String(); got != want {
+		t.Errorf("String() before Create():\nwant:\n%s\ngot:\n%s", want, got)
+	}
+
+	tf.Create(t)
+	err := os.Symlink("README.md", filepath.Join(string(tf.Dir()), "LINK"))
+	if err != nil {
+		t.Fatalf("Symlink(); %v", err)
+	}
+	got := tf.Render(&fsfix.RenderOptions{Source: fsfix.BothTrees, HideTimes: true})
+	for _, line := range []string{
+		"DECLARED",
+		"├── LINK@                -               Lrwxrwxrwx -> README.md",
+		"├── missing.txt          DoNotCreate     -",
+		"└── repo/ [repo]         drwxr-xr-x      drwxr-xr-x",
+	} {
+		if !strings.Contains(got, line) {
+			t.Errorf("Render(BothTrees) does not contain %q:\n%s", line, got)
+		}
+	}
+	got = rf.Render(&fsfix.RenderOptions{Source: fsfix.CreatedTree, HideModes: true})
+	want = string(rf.Dir()) + ` [repo]
+├── .git/            2024-01-02T03:04:05Z
+└── cmd/             2024-01-02T03:04:05Z
+    └── tool/        2024-01-02T03:04:05Z
+        └── main.go  12B 2024-01-01T03:04:05Z
+`
+	if got != want {
+		t.Errorf("Render(CreatedTree):\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+// failedTB wraps a real testing.TB, reporting the test as failed and
+// capturing what is logged.
+type failedTB struct {
+	testing.TB
+	logged strings.Builder
+}
+
+func (f *failedTB) Helper()      {}
+func (f *failedTB) Failed() bool { return true }
+
+func (f *failedTB) Logf(format string, args ...any) {
+	f.logged.WriteString(fmt.Sprintf(format, args...))
+}
+
+func TestRenderTreeLoggedOnFailure(t *testing.T) {
+	tb := &failedTB{TB: t}
+	tf := fsfix.NewRootFixture("render-on-failure")
+	tf.AddFileFixture(t, "data.txt", &fsfix.FileFixtureArgs{Content: "data"})
+	tf.Create(tb)
+	tf.Cleanup()
+
+	logged := tb.logged.String()
+	if !strings.Contains(logged, "DECLARED") || !strings.Contains(logged, "└── data.txt") {
+		t.Errorf("Cleanup() after failure: want tree logged, got %q", logged)
+	}
+}
+
+func TestRenderDoesNotCallContentFunc(t *testing.T) {
+	var calls int
+
+	tf := fsfix.NewRootFixture("render")
+	df := tf.AddDirFixture(t, "gen", nil)
+	df.AddFileFixture(t, "data.txt", &fsfix.FileFixtureArgs{
+		ContentFunc: func(*fsfix.FileFixture) string {
+			calls++
+			return "generated"
+		},
+	})
+
+	got := df.Render(&fsfix.RenderOptions{HideTimes: true})
+	if !strings.Contains(got, "data.txt  -rw-r--r-- ContentFunc") || calls != 0 {
+		t.Errorf("Render() before Create(): want ContentFunc shown and not called, got %d calls:\n%s", calls, got)
+	}
+
+	tb := &failedTB{TB: t}
+	tf.Create(tb)
+	got = df.Render(&fsfix.RenderOptions{HideTimes: true})
+	if !strings.Contains(got, "data.txt  -rw-r--r-- 9B") {
+		t.Errorf("Render() after Create(): want the generated size:\n%s", got)
+	}
+	tf.Cleanup()
+	if calls != 1 {
+		t.Errorf("ContentFunc: got %d calls, want 1 by Create()", calls)
+	}
+}
@@ -0,0 +1,130 @@
+package test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestResetRestoresPristineTree(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b fsfix.Backend) {
+		tf := buildTree(t, b)
+		defer tf.Cleanup()
+		want := readTree(t, tf)
+		path := func(rel string) string {
+			return filepath.Join(string(tf.Dir()), filepath.FromSlash(rel))
+		}
+
+		mustDo(t, b.WriteFile(path("README.md"), []byte("# ReadMe"), 0644))
+		mustDo(t, b.Chmod(path("README.md"), 0400))
+		mustDo(t, b.Chtimes(path("repo/bin/tool"), time.Now(), time.Now()))
+		mustDo(t, b.Chmod(path("repo/bin"), 0500))
+		mustDo(t, b.RemoveAll(path("data")))
+		mustDo(t, b.WriteFile(path("extra.txt"), []byte("extra"), 0644))
+		mustDo(t, b.MkdirAll(path("junk/deeper"), 0755))
+		mustDo(t, b.Chmod(path("junk"), 0))
+
+		tf.Reset(t)
+
+		assertSameTree(t, want, readTree(t, tf))
+	})
+}
+
+func TestResetSkipsUnchangedFiles(t *testing.T) {
+	b := &countingBackend{MemoryBackend: fsfix.NewMemoryBackend()}
+	tf := buildTree(t, b)
+	defer tf.Cleanup()
+	readme := filepath.Join(string(tf.Dir()), "README.md")
+
+	b.writes, b.reads = 0, 0
+	tf.Reset(t)
+	if b.writes != 0 || b.reads != 0 {
+		t.Errorf("Reset of an unchanged tree: got %d writes and %d reads, want none", b.writes, b.reads)
+	}
+
+	mustDo(t, b.WriteFile(readme, []byte("# Changed"), 0644))
+	b.writes = 0
+	tf.Reset(t)
+	if b.writes != 1 {
+		t.Errorf("Reset after changing one file: got %d writes, want 1", b.writes)
+	}
+	content, err := b.ReadFile(readme)
+	mustDo(t, err)
+	if string(content) != "# Readme" {
+		t.Errorf("README.md: got %q, want %q", content, "# Readme")
+	}
+}
+
+func TestResetDetectsRestoredModifiedTime(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b fsfix.Backend) {
+		assertResetRestoresSameSizeRewrite(t, b)
+	})
+	// Without a change time, e.g. off Linux, the recorded hash catches it
+	t.Run("no change time", func(t *testing.T) {
+		assertResetRestoresSameSizeRewrite(t, noChangeTimeBackend{fsfix.NewMemoryBackend()})
+	})
+}
+
+// assertResetRestoresSameSizeRewrite rewrites a file with content of the
+// same size and restores its modification time, then checks that Reset()
+// still restores the declared content.
+func assertResetRestoresSameSizeRewrite(t *testing.T, b fsfix.Backend) {
+	t.Helper()
+	tf := buildTree(t, b)
+	defer tf.Cleanup()
+	path := filepath.Join(string(tf.Dir()), "README.md")
+	info, err := b.Lstat(path)
+	mustDo(t, err)
+
+	mustDo(t, b.WriteFile(path, []byte("# ReadMe"), 0644))
+	mustDo(t, b.Chtimes(path, info.ModTime(), info.ModTime()))
+	tf.Reset(t)
+
+	content, err := b.ReadFile(path)
+	mustDo(t, err)
+	if string(content) != "# Readme" {
+		t.Errorf("README.md: got %q, want %q", content, "# Readme")
+	}
+}
+
+// noChangeTimeBackend is a MemoryBackend whose FileInfos carry no Sys(), as
+// on platforms where fsfix cannot read a file's status change time.
+type noChangeTimeBackend struct {
+	*fsfix.MemoryBackend
+}
+
+func (b noChangeTimeBackend) Lstat(name string) (os.FileInfo, error) {
+	info, err := b.MemoryBackend.Lstat(name)
+	if err != nil {
+		return nil, err
+	}
+	return noSysInfo{info}, nil
+}
+
+// noSysInfo hides the Sys() of a FileInfo.
+type noSysInfo struct {
+	os.FileInfo
+}
+
+func (noSysInfo) Sys() any { return nil }
+
+// countingBackend counts the files written to and read from a MemoryBackend.
+type countingBackend struct {
+	*fsfix.MemoryBackend
+	writes int
+	reads  int
+}
+
+func (b *countingBackend) ReadFile(name string) ([]byte, error) {
+	b.reads++
+	return b.MemoryBackend.ReadFile(name)
+}
+
+func (b *countingBackend) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	b.writes++
+	return b.MemoryBackend.WriteFile(name, data, perm)
+}
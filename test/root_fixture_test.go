@@ -0,0 +1,82 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestRootFixtureBaseDir(t *testing.T) {
+	baseDir := dt.DirPath(t.TempDir())
+	tf := fsfix.NewRootFixtureWithArgs("base-dir", &fsfix.RootFixtureArgs{
+		BaseDir: baseDir,
+	})
+	ff := tf.AddFileFixture(t, "a.txt", nil)
+	tf.Create(t)
+
+	if tf.Dir().Dir() != baseDir {
+		t.Errorf("RootFixture.Dir() not created in BaseDir '%s'; got '%s'", baseDir, tf.Dir())
+	}
+	if !fileExists(t, ff.Filepath) {
+		t.Errorf("FileFixture.Filepath doesn't exist: %s", ff.Filepath)
+	}
+
+	tf.RemoveFiles(t)
+	if dirExists(t, tf.Dir()) {
+		t.Errorf("RemoveFiles() did not remove '%s' from within BaseDir", tf.Dir())
+	}
+	if !dirExists(t, baseDir) {
+		t.Errorf("RemoveFiles() removed the BaseDir '%s'", baseDir)
+	}
+}
+
+func TestNewRootFixtureInTempDir(t *testing.T) {
+	var dir dt.DirPath
+	t.Run("sub", func(t *testing.T) {
+		tf := fsfix.NewRootFixtureInTempDir(t)
+		tf.AddDirFixture(t, "data", nil)
+		tf.Create(t)
+		// Cleanup is a no-op; the testing package owns removal.
+		tf.Cleanup()
+
+		dir = tf.Dir()
+		if !dirExists(t, dir) {
+			t.Errorf("RootFixture.Dir() doesn't exist: %s", dir)
+		}
+		if !strings.Contains(string(dir), "TestNewRootFixtureInTempDir") {
+			t.Errorf("RootFixture.Dir() not under t.TempDir(); got '%s'", dir)
+		}
+	})
+	if dirExists(t, dir) {
+		t.Errorf("t.TempDir() based RootFixture not removed by testing package: %s", dir)
+	}
+}
+
+func TestCleanupRestoresPermissions(t *testing.T) {
+	tf := fsfix.NewRootFixture("cleanup-perms")
+	df := tf.AddDirFixture(t, "locked", nil)
+	ff := df.AddFileFixture(t, "read-only.txt", &fsfix.FileFixtureArgs{
+		Permissions: 0400,
+	})
+	ndf := df.AddDirFixture(t, "no-perms", nil)
+	ndf.AddFileFixture(t, "hidden.txt", nil)
+	tf.Create(t)
+
+	// Lock down the tree after creation, as code under test might.
+	for _, dp := range []dt.DirPath{ndf.Dir(), df.Dir()} {
+		err := dp.Chmod(0)
+		if err != nil {
+			t.Fatalf("Failed to chmod %s; %v", dp, err)
+		}
+	}
+
+	tf.Cleanup()
+	if dirExists(t, tf.Dir()) {
+		t.Errorf("Cleanup() did not remove '%s' containing permissionless entries", tf.Dir())
+	}
+	if fileExists(t, ff.Filepath) {
+		t.Errorf("Cleanup() did not remove read-only file '%s'", ff.Filepath)
+	}
+}
@@ -0,0 +1,98 @@
+//go:build unix
+
+package test
+
+import (
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestNamedPipeAndSocketFixtures(t *testing.T) {
+	tf := fsfix.NewRootFixture("special")
+	defer tf.Cleanup()
+
+	fifo := tf.AddFileFixture(t, "queue", &fsfix.FileFixtureArgs{
+		Kind:        fsfix.NamedPipeKind,
+		Permissions: 0600,
+	})
+	sock := tf.AddFileFixture(t, "run/app.sock", &fsfix.FileFixtureArgs{
+		Kind: fsfix.SocketKind,
+	})
+	// Deep enough to exceed sun_path, so bound via a shorter path
+	long := tf.AddFileFixture(t, dt.RelFilepath(strings.Repeat("nested-directory/", 8)+"long.sock"), &fsfix.FileFixtureArgs{
+		Kind: fsfix.SocketKind,
+	})
+	tf.Create(t)
+
+	assertMode(t, dt.EntryPath(fifo.Filepath), os.ModeNamedPipe|0600)
+	assertMode(t, dt.EntryPath(sock.Filepath), os.ModeSocket|0644)
+	if len(long.Filepath) <= 108 {
+		t.Fatalf("Socket path is not long enough to need a fallback: %s", long.Filepath)
+	}
+	assertMode(t, dt.EntryPath(long.Filepath), os.ModeSocket|0644)
+
+	// Both sockets are listening until cleanup
+	conn, err := net.Dial("unix", string(sock.Filepath))
+	if err != nil {
+		t.Fatalf("Failed to connect to socket fixture; %v", err)
+	}
+	_ = conn.Close()
+	// Relative to its directory, as the full path exceeds sun_path
+	t.Chdir(string(long.Filepath.Dir()))
+	conn, err = net.Dial("unix", "long.sock")
+	if err != nil {
+		t.Fatalf("Failed to connect to long socket fixture; %v", err)
+	}
+	_ = conn.Close()
+}
+
+func TestLongSocketPathOnAnotherFilesystem(t *testing.T) {
+	const shm = "/dev/shm"
+	info, err := os.Stat(shm)
+	if err != nil || !info.IsDir() {
+		t.Skipf("No %s to create fixtures in", shm)
+	}
+	tf := fsfix.NewRootFixtureWithArgs("special", &fsfix.RootFixtureArgs{BaseDir: shm})
+	defer tf.Cleanup()
+
+	long := tf.AddFileFixture(t, dt.RelFilepath(strings.Repeat("nested-directory/", 8)+"long.sock"), &fsfix.FileFixtureArgs{
+		Kind: fsfix.SocketKind,
+	})
+	tf.Create(t)
+
+	if len(long.Filepath) <= 108 {
+		t.Fatalf("Socket path is not long enough to need a fallback: %s", long.Filepath)
+	}
+	assertMode(t, dt.EntryPath(long.Filepath), os.ModeSocket|0644)
+}
+
+func TestDeviceNodeFixtures(t *testing.T) {
+	tf := fsfix.NewRootFixture("devices")
+	defer tf.Cleanup()
+
+	null := tf.AddFileFixture(t, "null", &fsfix.FileFixtureArgs{
+		Kind:        fsfix.CharDeviceKind,
+		DeviceMajor: 1,
+		DeviceMinor: 3,
+		Permissions: 0666,
+	})
+	// Skips the test when mknod isn't permitted
+	tf.Create(t)
+
+	assertMode(t, dt.EntryPath(null.Filepath), os.ModeDevice|os.ModeCharDevice|0666)
+	info, err := dt.EntryPath(null.Filepath).Lstat()
+	if err != nil {
+		t.Fatalf("Failed to stat device node; %v", err)
+	}
+	rdev := info.Sys().(*syscall.Stat_t).Rdev
+	null2, err := os.Stat("/dev/null")
+	if err == nil && rdev != null2.Sys().(*syscall.Stat_t).Rdev {
+		t.Errorf("Device number: want that of /dev/null, got %#x", rdev)
+	}
+}
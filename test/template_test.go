@@ -0,0 +1,89 @@
+package test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+// goService declares the layout shared by every service in the monorepo.
+var goService = func() *fsfix.Template {
+	tmpl := fsfix.NewTemplate("go-service")
+	rf := tmpl.AddRepoFixture(nil, "{{.name}}", nil)
+	rf.AddFileFixture(nil, "go.mod", &fsfix.FileFixtureArgs{
+		Content: "module example.com/{{.name}}\n",
+	})
+	rf.AddFileFixture(nil, "cmd/{{.name}}/main.go", &fsfix.FileFixtureArgs{
+		ContentFunc: func(ff *fsfix.FileFixture) string {
+			return fmt.Sprintf("package main // listens on %d\n", ff.Vars["port"])
+		},
+	})
+	cfg := rf.AddDirFixture(nil, "config", &fsfix.DirFixtureArgs{Permissions: 0750})
+	cfg.AddFileFixture(nil, "{{.name}}.yaml", &fsfix.FileFixtureArgs{
+		Content:     "port: {{.port}}\n",
+		Permissions: 0600,
+	})
+	return tmpl
+}()
+
+func TestMountTemplate(t *testing.T) {
+	tf := fsfix.NewRootFixture("mount")
+	defer tf.Cleanup()
+
+	tf.Mount(t, "services", goService, map[string]any{"name": "api", "port": 8080})
+	df := tf.AddDirFixture(t, "tools", nil)
+	nodes := df.Mount(t, ".", goService, map[string]any{"name": "migrate", "port": 9090})
+	tf.Create(t)
+
+	if len(nodes) != 1 {
+		t.Fatalf("Mount(): want 1 top-level fixture, got %d", len(nodes))
+	}
+	if rf, ok := nodes[0].(*fsfix.RepoFixture); !ok || rf.RelativePath() != dt.DirPath("tools/migrate") {
+		t.Errorf("Mount(): want RepoFixture tools/migrate, got %v", nodes[0])
+	}
+	for path, want := range map[string]string{
+		"services/api/go.mod":               "module example.com/api\n",
+		"services/api/cmd/api/main.go":      "package main // listens on 8080\n",
+		"services/api/config/api.yaml":      "port: 8080\n",
+		"tools/migrate/go.mod":              "module example.com/migrate\n",
+		"tools/migrate/config/migrate.yaml": "port: 9090\n",
+	} {
+		ff, ok := tf.Lookup(path).(*fsfix.FileFixture)
+		if !ok {
+			t.Errorf("%s: not declared", path)
+			continue
+		}
+		content, err := dt.ReadFile(ff.Filepath)
+		if err != nil || string(content) != want {
+			t.Errorf("%s: want %q, got %q (%v)", path, want, content, err)
+		}
+	}
+	cfg, ok := tf.Lookup("services/api/config").(*fsfix.DirFixture)
+	if !ok || cfg.Permissions != 0750 {
+		t.Errorf("services/api/config: want DirFixture with permissions 0750, got %v", cfg)
+	}
+	if !dirExists(t, dt.DirPathJoin(tf.Dir(), "services/api/.git")) {
+		t.Errorf("services/api: want a repository")
+	}
+
+	// Mounting twice at the same place duplicates paths
+	msg := expectFatal(t, func(tb testing.TB) {
+		tf.Mount(tb, "services", goService, map[string]any{"name": "api", "port": 8081})
+	})
+	if !strings.Contains(msg, "duplicates") {
+		t.Errorf("Duplicate Mount(): want fatal, got %q", msg)
+	}
+}
+
+func TestMountTemplateMissingVar(t *testing.T) {
+	tf := fsfix.NewRootFixture("mount-missing-var")
+	msg := expectFatal(t, func(tb testing.TB) {
+		tf.Mount(tb, ".", goService, map[string]any{"port": 8080})
+	})
+	if !strings.Contains(msg, "go-service") || !strings.Contains(msg, "name") {
+		t.Errorf("Mount() without a variable: want fatal naming it, got %q", msg)
+	}
+}
@@ -0,0 +1,24 @@
+//go:build linux
+
+package test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+func assertAccessTime(t *testing.T, ep dt.EntryPath, want time.Time) {
+	t.Helper()
+	info, err := ep.Lstat()
+	if err != nil {
+		t.Fatalf("Failed to stat %s; %v", ep, err)
+	}
+	st := info.Sys().(*syscall.Stat_t)
+	got := time.Unix(st.Atim.Unix())
+	if !got.Equal(want) {
+		t.Errorf("Access time of %s: want %s, got %s", ep, want, got)
+	}
+}
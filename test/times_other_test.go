@@ -0,0 +1,15 @@
+//go:build !linux
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+func assertAccessTime(t *testing.T, _ dt.EntryPath, _ time.Time) {
+	t.Helper()
+	t.Log("Access time assertions are only implemented on Linux")
+}
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestDirFixtureModifiedTime(t *testing.T) {
+	want := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tf := fsfix.NewRootFixture("dir-mtime")
+	defer tf.Cleanup()
+	df := tf.AddDirFixture(t, "data", &fsfix.DirFixtureArgs{
+		ModifiedTime: want,
+	})
+	df.AddFileFixture(t, "nested/file.txt", nil)
+	rf := tf.AddRepoFixture(t, "repo", &fsfix.RepoFixtureArgs{
+		ModifiedTime: want,
+	})
+	// A root-level file inside a dir fixture is created after the dir
+	tf.AddFileFixture(t, "data/late.txt", nil)
+	tf.Create(t)
+
+	assertModTime(t, dt.EntryPath(df.Dir()), want)
+	assertModTime(t, dt.EntryPath(rf.Dir()), want)
+}
+
+func TestRootFixtureBaseTime(t *testing.T) {
+	tests := []struct {
+		name string
+		base time.Time
+	}{
+		{"modern", time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)},
+		{"pre-1970", time.Date(1960, 3, 15, 8, 30, 0, 0, time.UTC)},
+		{"far-future", time.Date(2300, 12, 31, 23, 59, 59, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.base.Year() > 2262 && runtime.GOOS != "linux" {
+				t.Skip("Times after 2262 are only supported on Linux")
+			}
+			tf := fsfix.NewRootFixtureWithArgs("base-time", &fsfix.RootFixtureArgs{
+				BaseTime: tt.base,
+			})
+			defer tf.Cleanup()
+			df := tf.AddDirFixture(t, "logs", &fsfix.DirFixtureArgs{
+				ModifiedOffset: "-24h",
+			})
+			old := df.AddFileFixture(t, "old.log", &fsfix.FileFixtureArgs{
+				ModifiedOffset: "-72h",
+				AccessedOffset: "-1h",
+			})
+			plain := tf.AddFileFixture(t, "plain.txt", nil)
+			tf.Create(t)
+
+			assertModTime(t, dt.EntryPath(tf.Dir()), tt.base)
+			assertModTime(t, dt.EntryPath(df.Dir()), tt.base.Add(-24*time.Hour))
+			assertModTime(t, dt.EntryPath(old.Filepath), tt.base.Add(-72*time.Hour))
+			assertAccessTime(t, dt.EntryPath(old.Filepath), tt.base.Add(-time.Hour))
+			assertModTime(t, dt.EntryPath(plain.Filepath), tt.base)
+		})
+	}
+}
+
+func TestInvalidTimeOffset(t *testing.T) {
+	tf := fsfix.NewRootFixture("bad-offset")
+	msg := expectFatal(t, func(tb testing.TB) {
+		tf.AddFileFixture(tb, "a.txt", &fsfix.FileFixtureArgs{
+			ModifiedOffset: "three days ago",
+		})
+	})
+	if msg == "" {
+		t.Errorf("Invalid ModifiedOffset was not rejected")
+	}
+}
+
+func assertModTime(t *testing.T, ep dt.EntryPath, want time.Time) {
+	t.Helper()
+	info, err := ep.Lstat()
+	if err != nil {
+		t.Fatalf("Failed to stat %s; %v", ep, err)
+	}
+	if !info.ModTime().Equal(want) {
+		t.Errorf("Modification time of %s: want %s, got %s", ep, want, info.ModTime())
+	}
+}
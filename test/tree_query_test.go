@@ -0,0 +1,128 @@
+package test
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+// nodePath returns the slash-separated path of n relative to the fixture root.
+func nodePath(t *testing.T, n fsfix.Node) string {
+	t.Helper()
+	switch f := n.(type) {
+	case *fsfix.FileFixture:
+		return filepath.ToSlash(string(f.RelativePath()))
+	case *fsfix.DirFixture:
+		return filepath.ToSlash(string(f.RelativePath()))
+	case *fsfix.RepoFixture:
+		return filepath.ToSlash(string(f.RelativePath()))
+	}
+	t.Fatalf("Unexpected node type %T", n)
+	return ""
+}
+
+func TestTreeQueries(t *testing.T) {
+	tf := fsfix.NewRootFixture("tree-query")
+	defer tf.Cleanup()
+
+	readme := tf.AddFileFixture(t, "README.md", nil)
+	rf := tf.AddRepoFixture(t, "repo", nil)
+	main := rf.AddFileFixture(t, "cmd/tool/main.go", nil)
+	rf.AddFileFixture(t, "go.mod", nil)
+	docs := tf.AddDirFixture(t, "docs", nil)
+	docs.AddFileFixture(t, "intro.md", nil)
+	guide := docs.AddDirFixture(t, "guide", nil)
+	guide.AddFileFixture(t, "setup.md", nil)
+	tf.AddFileFixture(t, "docs-index.md", nil)
+
+	want := []string{
+		"README.md",
+		"docs",
+		"docs/guide",
+		"docs/guide/setup.md",
+		"docs/intro.md",
+		"docs-index.md",
+		"repo",
+		"repo/cmd/tool/main.go",
+		"repo/go.mod",
+	}
+	for _, when := range []string{"before Create()", "after Create()"} {
+		if when == "after Create()" {
+			tf.Create(t)
+		}
+
+		var got []string
+		err := tf.Walk(func(n fsfix.Node) error {
+			got = append(got, nodePath(t, n))
+			return nil
+		})
+		if err != nil || !slices.Equal(got, want) {
+			t.Errorf("Walk() %s: want %v, got %v (%v)", when, want, got, err)
+		}
+
+		if n := tf.Lookup("README.md"); n != readme {
+			t.Errorf("Lookup(README.md) %s: want %v, got %v", when, readme, n)
+		}
+		if n := tf.Lookup("repo/cmd/tool/main.go"); n != main {
+			t.Errorf("Lookup(repo/cmd/tool/main.go) %s: want %v, got %v", when, main, n)
+		}
+		if n := tf.Lookup("repo"); n != fsfix.Node(rf) {
+			t.Errorf("Lookup(repo) %s: want %v, got %v", when, rf, n)
+		}
+		if n := rf.Lookup("./cmd/tool/main.go"); n != main {
+			t.Errorf("RepoFixture.Lookup(cmd/tool/main.go) %s: want %v, got %v", when, main, n)
+		}
+		if n := docs.Lookup("guide"); n != fsfix.Node(guide) {
+			t.Errorf("DirFixture.Lookup(guide) %s: want %v, got %v", when, guide, n)
+		}
+		if n := tf.Lookup("repo/cmd"); n != nil {
+			t.Errorf("Lookup() of implied directory %s: want nil, got %v", when, n)
+		}
+		if n := docs.Lookup("../README.md"); n != nil {
+			t.Errorf("DirFixture.Lookup() outside the directory %s: want nil, got %v", when, n)
+		}
+		if n := tf.Lookup("missing.txt"); n != nil {
+			t.Errorf("Lookup() of undeclared path %s: want nil, got %v", when, n)
+		}
+	}
+
+	nodes, err := tf.Glob("docs/*.md")
+	if err != nil || len(nodes) != 1 || nodePath(t, nodes[0]) != "docs/intro.md" {
+		t.Errorf("Glob(docs/*.md): want [docs/intro.md], got %v (%v)", nodes, err)
+	}
+	nodes, err = docs.Glob("*")
+	if err != nil || len(nodes) != 2 {
+		t.Errorf("DirFixture.Glob(*): want 2 fixtures, got %v (%v)", nodes, err)
+	}
+	_, err = tf.Glob("[")
+	if !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("Glob([): want path.ErrBadPattern, got %v", err)
+	}
+
+	var visited []string
+	err = tf.Walk(func(n fsfix.Node) error {
+		p := nodePath(t, n)
+		visited = append(visited, p)
+		switch p {
+		case "docs":
+			return fs.SkipDir
+		case "repo":
+			return fs.SkipAll
+		}
+		return nil
+	})
+	wantVisited := []string{"README.md", "docs", "docs-index.md", "repo"}
+	if err != nil || !slices.Equal(visited, wantVisited) {
+		t.Errorf("Walk() with SkipDir and SkipAll: want %v, got %v (%v)", wantVisited, visited, err)
+	}
+	stop := errors.New("stop")
+	err = tf.Walk(func(fsfix.Node) error { return stop })
+	if !errors.Is(err, stop) {
+		t.Errorf("Walk() returning error: want %v, got %v", stop, err)
+	}
+}
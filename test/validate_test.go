@@ -0,0 +1,87 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestValidateFixtureName(t *testing.T) {
+	tests := []struct {
+		name string
+		want error
+	}{
+		{"main.go", nil},
+		{"internal/widgets/my-widget.go", nil},
+		{"..dotdot", nil},
+		{"a/../b", nil},
+		{"", fsfix.ErrInvalidFixtureName},
+		{".", fsfix.ErrInvalidFixtureName},
+		{"file\x00null.txt", fsfix.ErrInvalidFixtureName},
+		{"/etc/passwd", fsfix.ErrAbsoluteFixtureName},
+		{"..", fsfix.ErrFixtureNameEscapesRoot},
+		{"../../etc/x", fsfix.ErrFixtureNameEscapesRoot},
+		{"a/../../x", fsfix.ErrFixtureNameEscapesRoot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fsfix.ValidateFixtureName(tt.name)
+			if tt.want == nil && err != nil {
+				t.Errorf("ValidateFixtureName(%q): want nil, got %v", tt.name, err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("ValidateFixtureName(%q): want %v, got %v", tt.name, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestAddFixtureRejectsEscapingName(t *testing.T) {
+	tf := fsfix.NewRootFixture("escape-test")
+	df := tf.AddDirFixture(t, "a", nil)
+
+	// Escaping "a" into the root is fine; escaping the root is not.
+	df.AddFileFixture(t, "../sibling.txt", nil)
+
+	msg := expectFatal(t, func(tb testing.TB) {
+		df.AddFileFixture(tb, "../../escaped.txt", nil)
+	})
+	want := "RootFixture 'escape-test' > DirFixture 'a' > FileFixture '../../escaped.txt'"
+	if !strings.Contains(msg, want) {
+		t.Errorf("Fatal message doesn't name fixture chain '%s'; got '%s'", want, msg)
+	}
+
+	msg = expectFatal(t, func(tb testing.TB) {
+		df.AddFileFixture(tb, "../../allowed.txt", &fsfix.FileFixtureArgs{
+			AllowEscape: true,
+		})
+	})
+	if msg != "" {
+		t.Errorf("AllowEscape did not permit escaping name; got '%s'", msg)
+	}
+}
+
+func TestDirFixtureArgsFilesRejectEscapingName(t *testing.T) {
+	tf := fsfix.NewRootFixture("escape-test")
+
+	msg := expectFatal(t, func(tb testing.TB) {
+		tf.AddDirFixture(tb, "a", &fsfix.DirFixtureArgs{
+			Files: []*fsfix.FileFixture{{Name: "../../escaped-by-args.txt"}},
+		})
+	})
+	want := "RootFixture 'escape-test' > DirFixture 'a' > FileFixture '../../escaped-by-args.txt'"
+	if !strings.Contains(msg, want) {
+		t.Errorf("Fatal message doesn't name fixture chain '%s'; got '%s'", want, msg)
+	}
+
+	msg = expectFatal(t, func(tb testing.TB) {
+		tf.AddDirFixture(tb, "b", &fsfix.DirFixtureArgs{
+			Files: []*fsfix.FileFixture{{Name: "../../allowed.txt", AllowEscape: true}},
+		})
+	})
+	if msg != "" {
+		t.Errorf("AllowEscape did not permit escaping name; got '%s'", msg)
+	}
+}
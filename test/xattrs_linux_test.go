@@ -0,0 +1,45 @@
+//go:build linux
+
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mikeschinkel/go-fsfix"
+)
+
+func TestXAttrFixtures(t *testing.T) {
+	tf := fsfix.NewRootFixture("xattrs")
+	defer tf.Cleanup()
+
+	ff := tf.AddFileFixture(t, "data.bin", &fsfix.FileFixtureArgs{
+		Permissions: 0444,
+		XAttrs: map[string][]byte{
+			"user.origin":   []byte("https://example.com/data.bin"),
+			"user.checksum": []byte("abc123"),
+		},
+	})
+	df := tf.AddDirFixture(t, "cache", &fsfix.DirFixtureArgs{
+		Permissions: 0500,
+		XAttrs: map[string][]byte{
+			"user.empty": {},
+		},
+	})
+	// Skips the test if the temp filesystem lacks xattr support
+	tf.Create(t)
+
+	attrs := ff.ReadXAttrs(t)
+	if !bytes.Equal(attrs["user.origin"], []byte("https://example.com/data.bin")) {
+		t.Errorf("user.origin: want 'https://example.com/data.bin', got '%s'", attrs["user.origin"])
+	}
+	if !bytes.Equal(attrs["user.checksum"], []byte("abc123")) {
+		t.Errorf("user.checksum: want 'abc123', got '%s'", attrs["user.checksum"])
+	}
+
+	attrs = df.ReadXAttrs(t)
+	value, ok := attrs["user.empty"]
+	if !ok || len(value) != 0 {
+		t.Errorf("user.empty: want present and empty, got %q (present=%t)", value, ok)
+	}
+}
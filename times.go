@@ -0,0 +1,120 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// clock resolves fixture timestamps against a RootFixture's base time so
+// whole trees can be given stable, reproducible times.
+type clock struct {
+	base     time.Time // Time relative offsets are applied to
+	explicit bool      // Whether base was configured, rather than the time of Build()
+}
+
+// newClock returns a clock based on baseTime, or on now if baseTime is zero.
+func newClock(baseTime time.Time) clock {
+	if baseTime.IsZero() {
+		return clock{base: time.Now()}
+	}
+	return clock{base: baseTime, explicit: true}
+}
+
+// clockOf returns the clock of the RootFixture at the top of f's hierarchy.
+func clockOf(f Fixture) clock {
+	rf := rootOf(f)
+	if rf == nil {
+		return newClock(time.Time{})
+	}
+	return rf.clock
+}
+
+// fixtureTimes holds the declared timestamps for a file or directory fixture.
+type fixtureTimes struct {
+	ModifiedTime   time.Time
+	AccessedTime   time.Time
+	ModifiedOffset string
+	AccessedOffset string
+}
+
+// resolve returns the access and modification times to apply. Absolute
+// times win over offsets, offsets are relative to the clock's base, and an
+// unspecified modification time defaults to the base only when the base was
+// configured. An unspecified access time follows the modification time.
+// Zero values mean "leave unchanged".
+func (c clock) resolve(ft fixtureTimes) (atime, mtime time.Time, err error) {
+	var offset time.Duration
+
+	mtime = ft.ModifiedTime
+	if mtime.IsZero() && ft.ModifiedOffset != "" {
+		offset, err = parseOffset(ft.ModifiedOffset)
+		if err != nil {
+			goto end
+		}
+		mtime = c.base.Add(offset)
+	}
+	if mtime.IsZero() && c.explicit {
+		mtime = c.base
+	}
+	atime = ft.AccessedTime
+	if atime.IsZero() && ft.AccessedOffset != "" {
+		offset, err = parseOffset(ft.AccessedOffset)
+		if err != nil {
+			goto end
+		}
+		atime = c.base.Add(offset)
+	}
+	if atime.IsZero() {
+		atime = mtime
+	}
+end:
+	return atime, mtime, err
+}
+
+// apply sets the resolved times on path in b, if any were declared.
+func (c clock) apply(b Backend, path dt.EntryPath, ft fixtureTimes) (err error) {
+	var atime, mtime time.Time
+
+	atime, mtime, err = c.resolve(ft)
+	if err != nil {
+		err = dt.WithErr(err, "path", path)
+		goto end
+	}
+	if atime.IsZero() && mtime.IsZero() {
+		goto end
+	}
+	err = b.Chtimes(string(path), atime, mtime)
+	if err != nil {
+		err = dt.NewErr(ErrFailedToSetTimes, "path", path, "atime", atime, "mtime", mtime, err)
+	}
+end:
+	return err
+}
+
+// validateOffsets returns an error if either offset in ft cannot be parsed.
+func (ft fixtureTimes) validateOffsets() (err error) {
+	_, err = parseOffset(ft.ModifiedOffset)
+	if err != nil {
+		goto end
+	}
+	_, err = parseOffset(ft.AccessedOffset)
+end:
+	return err
+}
+
+// parseOffset parses a relative time offset such as "-72h"; an empty
+// offset parses as zero.
+func parseOffset(offset string) (d time.Duration, err error) {
+	if offset == "" {
+		goto end
+	}
+	d, err = time.ParseDuration(offset)
+	if err != nil {
+		err = dt.NewErr(ErrInvalidTimeOffset, "offset", offset, err)
+	}
+end:
+	return d, err
+}
@@ -0,0 +1,51 @@
+//go:build linux
+
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// utimeOmit is UTIME_OMIT, telling utimensat(2) to leave a time unchanged.
+const utimeOmit = (1 << 30) - 2
+
+// chtimes sets the access and modification times of path, leaving zero times
+// unchanged. Unlike os.Chtimes() it does not go via time.UnixNano(), which
+// overflows for times outside the years 1678 to 2262.
+func chtimes(path string, atime, mtime time.Time) (err error) {
+	err = syscall.UtimesNano(path, []syscall.Timespec{timespec(atime), timespec(mtime)})
+	if err != nil {
+		err = &fs.PathError{Op: "chtimes", Path: path, Err: err}
+	}
+	return err
+}
+
+// timespec converts t to a syscall.Timespec, or to UTIME_OMIT if t is zero.
+func timespec(t time.Time) (ts syscall.Timespec) {
+	if t.IsZero() {
+		setInt(&ts.Nsec, utimeOmit)
+		return ts
+	}
+	setInt(&ts.Sec, t.Unix())
+	setInt(&ts.Nsec, int64(t.Nanosecond()))
+	return ts
+}
+
+// changeStamp returns the status change time, in nanoseconds, and inode of
+// info, where the platform records them.
+func changeStamp(info fs.FileInfo) (ctimeNsec int64, inode uint64) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ctimeNsec, inode
+	}
+	return syscall.TimespecToNsec(st.Ctim), uint64(st.Ino)
+}
+
+// setInt assigns v to a Timespec field whose width varies by architecture.
+func setInt[T ~int32 | ~int64](p *T, v int64) {
+	*p = T(v)
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"io/fs"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// chtimes sets the access and modification times of path, leaving zero times
+// unchanged. Times outside the years 1678 to 2262 are not supported here.
+func chtimes(path string, atime, mtime time.Time) error {
+	return dt.Chtimes(path, atime, mtime)
+}
+
+// changeStamp returns zero values as status change times and inodes are not
+// read on this platform.
+func changeStamp(fs.FileInfo) (ctimeNsec int64, inode uint64) {
+	return ctimeNsec, inode
+}
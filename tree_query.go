@@ -0,0 +1,197 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Node is a fixture in a declared tree: a *FileFixture, *DirFixture or
+// *RepoFixture, as returned by Lookup(), Glob() and Walk(). Use a type
+// switch or assertion to get at the fixture itself.
+type Node interface {
+	parentFixture() Fixture
+	fixtureLabel() string
+}
+
+// _ is a compile-time check to ensure FileFixture implements the Node interface.
+var _ Node = (*FileFixture)(nil)
+
+// treeNode is a Node and its slash-separated path relative to the fixture
+// the tree is being queried from.
+type treeNode struct {
+	path string
+	node Node
+}
+
+// Lookup returns the fixture declared at relPath, relative to the root, or
+// nil if none was. Directories only implied by a descendant's name have no
+// fixture so return nil. Works both before and after Create().
+func (rf *RootFixture) Lookup(relPath string) Node {
+	return lookupNode(rf, relPath)
+}
+
+// Glob returns the fixtures whose paths relative to the root match pattern,
+// using the syntax of path.Match, in the order Walk() visits them. The only
+// possible error is path.ErrBadPattern.
+func (rf *RootFixture) Glob(pattern string) ([]Node, error) {
+	return globNodes(rf, pattern)
+}
+
+// Walk calls fn for every fixture declared beneath the root, files included,
+// in lexical order of their paths with each directory before its contents.
+// Returning fs.SkipDir from fn for a directory skips its contents, returning
+// fs.SkipAll stops the walk, and any other error stops the walk and is
+// returned. Works both before and after Create().
+func (rf *RootFixture) Walk(fn func(Node) error) error {
+	return walkNodes(rf, fn)
+}
+
+// Lookup returns the fixture declared at relPath, relative to this
+// directory, or nil if none was. See RootFixture.Lookup().
+func (df *DirFixture) Lookup(relPath string) Node {
+	return lookupNode(df, relPath)
+}
+
+// Glob returns the fixtures whose paths relative to this directory match
+// pattern. See RootFixture.Glob().
+func (df *DirFixture) Glob(pattern string) ([]Node, error) {
+	return globNodes(df, pattern)
+}
+
+// Walk calls fn for every fixture declared beneath this directory. See
+// RootFixture.Walk().
+func (df *DirFixture) Walk(fn func(Node) error) error {
+	return walkNodes(df, fn)
+}
+
+// Lookup returns the fixture declared at relPath, relative to this
+// repository, or nil if none was. See RootFixture.Lookup().
+func (rf *RepoFixture) Lookup(relPath string) Node {
+	return lookupNode(rf, relPath)
+}
+
+// lookupNode returns the fixture declared at relPath beneath f, or nil,
+// resolving it through the root's path index rather than walking the tree.
+func lookupNode(f Fixture, relPath string) Node {
+	rel := path.Clean(filepath.ToSlash(relPath))
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") || path.IsAbs(rel) {
+		return nil
+	}
+	rf := rootOf(f)
+	if rf == nil {
+		return nil
+	}
+	e, ok := rf.pathIndex[filepath.Join(string(f.RelativePath()), filepath.FromSlash(rel))]
+	if !ok || e.implied {
+		return nil
+	}
+	node, _ := e.fixture.(Node)
+	return node
+}
+
+// globNodes returns the fixtures beneath f whose paths match pattern.
+func globNodes(f Fixture, pattern string) (nodes []Node, err error) {
+	var matched bool
+
+	// Validate the pattern even if there is nothing to match it against
+	_, err = path.Match(pattern, "")
+	if err != nil {
+		goto end
+	}
+	for _, tn := range treeNodes(f) {
+		matched, _ = path.Match(pattern, tn.path)
+		if matched {
+			nodes = append(nodes, tn.node)
+		}
+	}
+end:
+	return nodes, err
+}
+
+// walkNodes calls fn for every fixture beneath f. See RootFixture.Walk().
+func walkNodes(f Fixture, fn func(Node) error) (err error) {
+	var skipped string
+
+	for _, tn := range treeNodes(f) {
+		if skipped != "" && strings.HasPrefix(tn.path, skipped) {
+			continue
+		}
+		err = fn(tn.node)
+		switch {
+		case err == nil:
+		case errors.Is(err, fs.SkipDir) && isDirNode(tn.node):
+			skipped = tn.path + "/"
+			err = nil
+		case errors.Is(err, fs.SkipAll):
+			err = nil
+			goto end
+		default:
+			goto end
+		}
+	}
+end:
+	return err
+}
+
+// isDirNode reports whether n is a directory or repository fixture.
+func isDirNode(n Node) bool {
+	_, ok := n.(Fixture)
+	return ok
+}
+
+// treeNodes returns every fixture declared beneath f with its path relative
+// to f, sorted so each directory precedes its contents.
+func treeNodes(f Fixture) []treeNode {
+	var nodes []treeNode
+	var collect func(dir string, f Fixture)
+
+	collect = func(dir string, f Fixture) {
+		files, children := fixturesOf(f)
+		for _, ff := range files {
+			nodes = append(nodes, treeNode{path: joinSlash(dir, string(ff.Name)), node: ff})
+		}
+		for _, cf := range children {
+			var name string
+			switch c := cf.(type) {
+			case *DirFixture:
+				name = string(c.Name)
+			case *RepoFixture:
+				name = string(c.Name)
+			default:
+				continue
+			}
+			child := joinSlash(dir, name)
+			nodes = append(nodes, treeNode{path: child, node: cf})
+			collect(child, cf)
+		}
+	}
+	collect(".", f)
+	slices.SortStableFunc(nodes, func(a, b treeNode) int {
+		return slices.Compare(strings.Split(a.path, "/"), strings.Split(b.path, "/"))
+	})
+	return nodes
+}
+
+// joinSlash joins name, which may use OS separators, to the slash-separated dir.
+func joinSlash(dir, name string) string {
+	return path.Join(dir, filepath.ToSlash(name))
+}
+
+// fixturesOf returns the file and child fixtures declared directly in f.
+func fixturesOf(f Fixture) (files []*FileFixture, children []Fixture) {
+	switch p := f.(type) {
+	case *RootFixture:
+		files, children = p.FileFixtures, p.ChildFixtures
+	case *DirFixture:
+		files, children = p.FileFixtures, p.ChildFixtures
+	case *RepoFixture:
+		files, children = p.FileFixtures, p.ChildFixtures
+	}
+	return files, children
+}
@@ -0,0 +1,70 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// ValidateFixtureName checks that name is usable as a fixture name added
+// directly to a RootFixture, i.e. that it is not empty, does not contain a NUL,
+// is not absolute and does not escape the fixture root.
+func ValidateFixtureName(name string) error {
+	return validateName(".", name, false)
+}
+
+// validateName checks name as if joined onto parentRel, the parent's path
+// relative to the fixture root. Escaping the root is permitted only when
+// allowEscape is true.
+func validateName(parentRel dt.DirPath, name string, allowEscape bool) (err error) {
+	var rel string
+
+	switch {
+	case name == "":
+		err = dt.NewErr(ErrInvalidFixtureName, dt.ErrEmpty, "name", name)
+	case strings.ContainsRune(name, 0):
+		err = dt.NewErr(ErrInvalidFixtureName, dt.ErrControlCharacter, "name", fmt.Sprintf("%q", name))
+	case filepath.IsAbs(name) || filepath.VolumeName(name) != "":
+		err = dt.NewErr(ErrAbsoluteFixtureName, "name", name)
+	case filepath.Clean(name) == ".":
+		err = dt.NewErr(ErrInvalidFixtureName, "name", name)
+	case allowEscape:
+		// The test has explicitly opted in to escaping the fixture root
+	default:
+		rel = filepath.Clean(filepath.Join(string(parentRel), name))
+		if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			err = dt.NewErr(ErrFixtureNameEscapesRoot, "name", name, "resolves_to", rel)
+		}
+	}
+	return err
+}
+
+// validateChild fails t if name is not valid for a fixture of the given kind
+// being added to parent, naming the full chain of fixtures in the message.
+func validateChild(t testing.TB, parent Fixture, kind string, name string, allowEscape bool) {
+	err := validateName(parent.RelativePath(), name, allowEscape)
+	if err == nil {
+		return
+	}
+	if t != nil {
+		t.Helper()
+	}
+	fatalf(t, "Invalid name for %s; %v", fixtureChain(parent, kind, name), err)
+}
+
+// fixtureChain describes the path through the fixture hierarchy to a child of
+// parent, e.g. "RootFixture 'my-test' > DirFixture 'src' > FileFixture 'x'".
+func fixtureChain(parent Fixture, kind string, name string) string {
+	// Keep NULs out of test output
+	name = strings.ReplaceAll(name, "\x00", `\x00`)
+	labels := []string{fmt.Sprintf("%s '%s'", kind, name)}
+	for f := parent; f != nil; f = f.parentFixture() {
+		labels = append([]string{f.fixtureLabel()}, labels...)
+	}
+	return strings.Join(labels, " > ")
+}
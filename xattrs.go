@@ -0,0 +1,67 @@
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// setXAttrs applies attrs to path in name order.
+func setXAttrs(path dt.EntryPath, attrs map[string][]byte) (err error) {
+	for _, name := range slices.Sorted(maps.Keys(attrs)) {
+		err = setXAttr(string(path), name, attrs[name])
+		if err == nil {
+			continue
+		}
+		if xattrUnsupported(err) {
+			err = dt.NewErr(ErrXAttrsUnsupported, "path", path, "name", name, err)
+			goto end
+		}
+		err = dt.NewErr(ErrFailedToSetXAttr, "path", path, "name", name, err)
+		goto end
+	}
+end:
+	return err
+}
+
+// ReadXAttrs returns the extended attributes of path, e.g. for assertions
+// after the code under test has run.
+func ReadXAttrs(path dt.EntryPath) (attrs map[string][]byte, err error) {
+	attrs, err = readXAttrs(string(path))
+	if err == nil {
+		goto end
+	}
+	if xattrUnsupported(err) {
+		err = dt.NewErr(ErrXAttrsUnsupported, "path", path, err)
+		goto end
+	}
+	err = dt.NewErr(ErrFailedToReadXAttrs, "path", path, err)
+end:
+	return attrs, err
+}
+
+// mustReadXAttrs reads the extended attributes of path, failing t on error.
+func mustReadXAttrs(t testing.TB, path dt.EntryPath) map[string][]byte {
+	t.Helper()
+	attrs, err := ReadXAttrs(path)
+	if err != nil {
+		t.Fatalf("Failed to read extended attributes; %v", err)
+	}
+	return attrs
+}
+
+// ReadXAttrs returns the extended attributes currently set on the file.
+func (ff *FileFixture) ReadXAttrs(t testing.TB) map[string][]byte {
+	t.Helper()
+	return mustReadXAttrs(t, dt.EntryPath(ff.Filepath))
+}
+
+// ReadXAttrs returns the extended attributes currently set on the directory.
+func (df *DirFixture) ReadXAttrs(t testing.TB) map[string][]byte {
+	t.Helper()
+	return mustReadXAttrs(t, dt.EntryPath(df.Dir()))
+}
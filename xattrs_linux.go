@@ -0,0 +1,79 @@
+//go:build linux
+
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"syscall"
+)
+
+// setXAttr sets the extended attribute name on path.
+func setXAttr(path, name string, value []byte) (err error) {
+	err = syscall.Setxattr(path, name, value, 0)
+	if err != nil {
+		err = &fs.PathError{Op: "setxattr", Path: path, Err: err}
+	}
+	return err
+}
+
+// readXAttrs returns every extended attribute set on path.
+func readXAttrs(path string) (attrs map[string][]byte, err error) {
+	var names []byte
+	var value []byte
+
+	names, err = xattrCall(func(buf []byte) (int, error) {
+		return syscall.Listxattr(path, buf)
+	})
+	if err != nil {
+		err = &fs.PathError{Op: "listxattr", Path: path, Err: err}
+		goto end
+	}
+	attrs = make(map[string][]byte)
+	for name := range bytes.SplitSeq(names, []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		value, err = xattrCall(func(buf []byte) (int, error) {
+			return syscall.Getxattr(path, string(name), buf)
+		})
+		if err != nil {
+			err = &fs.PathError{Op: "getxattr", Path: path, Err: err}
+			goto end
+		}
+		attrs[string(name)] = value
+	}
+end:
+	return attrs, err
+}
+
+// xattrCall sizes a buffer with call, then fills it, retrying if the
+// attribute grew in between.
+func xattrCall(call func([]byte) (int, error)) (buf []byte, err error) {
+	var n int
+	for {
+		n, err = call(nil)
+		if err != nil {
+			goto end
+		}
+		buf = make([]byte, n)
+		n, err = call(buf)
+		if !errors.Is(err, syscall.ERANGE) {
+			break
+		}
+	}
+	if err == nil {
+		buf = buf[:n]
+	}
+end:
+	return buf, err
+}
+
+// xattrUnsupported reports whether err means the filesystem has no
+// extended attribute support.
+func xattrUnsupported(err error) bool {
+	return errors.Is(err, syscall.ENOTSUP)
+}
@@ -0,0 +1,26 @@
+//go:build !linux
+
+// Package fsfix provides testing utilities for creating and managing test fixtures.
+// It supports creating temporary file systems, directories, and Git repositories for testing.
+package fsfix
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// setXAttr reports that extended attributes are only supported on Linux.
+func setXAttr(path, _ string, _ []byte) error {
+	return &fs.PathError{Op: "setxattr", Path: path, Err: errors.ErrUnsupported}
+}
+
+// readXAttrs reports that extended attributes are only supported on Linux.
+func readXAttrs(path string) (map[string][]byte, error) {
+	return nil, &fs.PathError{Op: "listxattr", Path: path, Err: errors.ErrUnsupported}
+}
+
+// xattrUnsupported reports whether err means the platform or filesystem has
+// no extended attribute support.
+func xattrUnsupported(err error) bool {
+	return errors.Is(err, errors.ErrUnsupported)
+}